@@ -0,0 +1,26 @@
+package base
+
+import (
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+)
+
+func init() {
+	SetConfigDefault("Ip.TrustedProxies", gzutil.DefaultTrustedProxies)
+}
+
+// initClientIP 按照 ip 配置设置可信代理, 配置热重载时同步更新
+func initClientIP() {
+	applyClientIP()
+	OnConfigChange("ip", func(_, _ *BaseConfig) {
+		applyClientIP()
+	})
+}
+
+func applyClientIP() {
+	proxies := GetConfig().Ip.TrustedProxies
+	if err := gzutil.SetTrustedProxies(proxies, GetConfig().Ip.Headers); err != nil {
+		gzconsole.Echo.Warnf("⚠️  警告: ip.trustedProxies 配置有误, 不信任任何代理: %s\n", err)
+		_ = gzutil.SetTrustedProxies(nil, GetConfig().Ip.Headers)
+	}
+}
@@ -1,32 +1,86 @@
 package base
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
+	"github.com/w01fb0ss/gin-starter/pkg/gzredact"
 )
 
 type BaseConfig struct {
-	App    app             `mapstructure:"app"`
-	Db     []databasesConf `mapstructure:"databases"`
-	Redis  redisConf       `mapstructure:"redis"`
-	Mongo  mongoConf       `mapstructure:"mongo"`
-	Logger logger          `mapstructure:"logger"`
-	Casbin casbin          `mapstructure:"casbin"`
-	Jwt    jwt             `mapstructure:"jwt"`
-	Oss    oss             `mapstructure:"oss"`
+	App     app             `mapstructure:"app"`
+	Db      []databasesConf `mapstructure:"databases"`
+	Redis   redisConf       `mapstructure:"redis"`
+	Mongo   mongoConf       `mapstructure:"mongo"`
+	Logger  logger          `mapstructure:"log"`
+	Casbin  casbin          `mapstructure:"casbin"`
+	Jwt     jwt             `mapstructure:"jwt"`
+	Oss     oss             `mapstructure:"oss"`
+	Admin   admin           `mapstructure:"admin"`
+	Redact  redact          `mapstructure:"redact"`
+	Audit   audit           `mapstructure:"audit"`
+	Ip      ipConf          `mapstructure:"ip"`
+	Trace   traceConf       `mapstructure:"trace"`
+	Metrics metricsConf     `mapstructure:"metrics"`
+	Health  healthConf      `mapstructure:"health"`
 }
 type app struct {
-	Name         string `mapstructure:"name"`
-	Env          string `mapstructure:"env"`
-	Addr         string `mapstructure:"addr"`
-	Timeout      int    `mapstructure:"timeout"`
-	RouterPrefix string `mapstructure:"routerPrefix"`
-	CacheCap     int    `mapstructure:"cacheCap"`
-	CacheShard   int    `mapstructure:"cacheShard"`
-	CacheClear   int    `mapstructure:"cacheClear"`
+	Name            string `mapstructure:"name"`
+	Env             string `mapstructure:"env"`
+	Addr            string `mapstructure:"addr"`
+	Timeout         int    `mapstructure:"timeout"`         // HTTP 服务停止时等待进行中的请求的最长时间, 单位秒, 为 0 时使用 shutdownTimeout
+	ShutdownTimeout int    `mapstructure:"shutdownTimeout"` // 所有服务停止的最长等待时间, 单位秒, 默认 30
+	UpgradeTimeout  int    `mapstructure:"upgradeTimeout"`  // 平滑重启时等待新进程就绪的最长时间, 单位秒, 默认 60
+	RouterPrefix    string `mapstructure:"routerPrefix"`
+	CacheCap        int    `mapstructure:"cacheCap"`
+	CacheShard      int    `mapstructure:"cacheShard"`
+	CacheClear      int    `mapstructure:"cacheClear"`
+	Server          server `mapstructure:"server"`
+}
+
+// server HTTP 服务的参数, 时间的单位都是秒
+type server struct {
+	ReadTimeout       int            `mapstructure:"readTimeout"`       // 读取整个请求(包括请求体)的超时时间, 默认 0 不限制
+	ReadHeaderTimeout int            `mapstructure:"readHeaderTimeout"` // 读取请求头的超时时间, 默认 10
+	WriteTimeout      int            `mapstructure:"writeTimeout"`      // 写响应的超时时间, 默认 0 不限制, 有大文件下载、SSE 等长响应时不要设置
+	IdleTimeout       int            `mapstructure:"idleTimeout"`       // keep-alive 连接的空闲超时时间, 默认 120
+	MaxHeaderBytes    int            `mapstructure:"maxHeaderBytes"`    // 请求头的最大字节数, 默认 1MB
+	DisableKeepAlive  bool           `mapstructure:"disableKeepAlive"`  // 关闭 HTTP keep-alive, 每个请求后断开连接
+	TcpKeepAlive      int            `mapstructure:"tcpKeepAlive"`      // TCP keep-alive 的探测间隔, 默认 15, 小于 0 时关闭
+	Listeners         []listenerConf `mapstructure:"listeners"`         // 除 app.addr 外额外监听的地址, 如内网的管理端口、unix socket
+	H2c               bool           `mapstructure:"h2c"`               // 非 TLS 的监听同时支持 HTTP/2(prior knowledge), 用于内网 gRPC-gateway 等
+	PreStopDelay      int            `mapstructure:"preStopDelay"`      // 停止时先标记为未就绪并继续处理请求的时间, 等待负载均衡摘除实例, 需小于 shutdownTimeout, 默认 0
+	Tls               tlsConf        `mapstructure:"tls"`
+}
+
+// tlsConf 开启后所有监听都使用 TLS, 同时支持 HTTP/2
+type tlsConf struct {
+	Enable         bool       `mapstructure:"enable"`
+	Certs          []certConf `mapstructure:"certs"`          // 证书列表, 按 SNI 选择, 没有匹配时使用第一个
+	MinVersion     string     `mapstructure:"minVersion"`     // 最低版本: 1.0、1.1、1.2、1.3, 默认 1.2
+	CipherSuites   []string   `mapstructure:"cipherSuites"`   // 加密套件名称, 只对 TLS 1.2 及以下生效, 默认使用 Go 的推荐列表
+	ClientCa       string     `mapstructure:"clientCa"`       // 校验客户端证书的 CA 文件, 设置后开启双向认证
+	ClientAuth     string     `mapstructure:"clientAuth"`     // none、request、require、verifyIfGiven、requireAndVerify, 设置 clientCa 时默认 requireAndVerify
+	ReloadInterval int        `mapstructure:"reloadInterval"` // 检查证书文件变化的间隔, 单位秒, 默认 60, 小于 0 时不检查
+}
+
+type certConf struct {
+	CertFile string `mapstructure:"certFile"`
+	KeyFile  string `mapstructure:"keyFile"`
+}
+
+type listenerConf struct {
+	Name    string `mapstructure:"name"`    // 名称, 用于输出提示
+	Network string `mapstructure:"network"` // tcp(默认)、tcp4、tcp6、unix
+	Addr    string `mapstructure:"addr"`    // 监听地址, network 为 unix 时为 socket 文件路径
+	Service string `mapstructure:"service"` // 所属的服务, 即服务结构体的名称(如 HttpServer), 为空时属于监听 app.addr 的服务
+	Handler string `mapstructure:"handler"` // 必填, engine 表示使用服务的全部路由, 其他值需要通过 IHttp.SetHandler 注册
 }
 type databasesConf struct {
 	Name            string `mapstructure:"name"`
@@ -49,17 +103,52 @@ type mongoConf struct {
 	URL string `mapstructure:"Url"`
 }
 type logger struct {
-	Path       string `mapstructure:"path"`
-	Mode       string `mapstructure:"mode"`
-	Logrotate  bool   `mapstructure:"logrotate"`
-	Recover    bool   `mapstructure:"recover"`
-	MaxSize    int    `mapstructure:"maxSize"`
-	MaxBackups int    `mapstructure:"maxBackups"`
-	MaxAge     int    `mapstructure:"maxAge"`
-	Compress   bool   `mapstructure:"compress"`
+	Level      string                 `mapstructure:"level"`
+	Path       string                 `mapstructure:"path"`
+	Layout     string                 `mapstructure:"layout"`    // 文件布局: level(默认, 每个级别一个文件) 或 single(每天一个文件)
+	ErrorFile  bool                   `mapstructure:"errorFile"` // single 布局下是否把 error 及以上级别额外输出到单独的文件
+	Mode       []string               `mapstructure:"mode"`      // 日志输出, 可以配置多个, 如 [console, file, syslog, tcp, ring]
+	Logrotate  bool                   `mapstructure:"logrotate"`
+	Recover    bool                   `mapstructure:"recover"`
+	MaxSize    int                    `mapstructure:"maxSize"`
+	MaxBackups int                    `mapstructure:"maxBackups"`
+	MaxAge     int                    `mapstructure:"maxAge"`
+	Compress   bool                   `mapstructure:"compress"`
+	Sampling   map[string]logSampling `mapstructure:"sampling"` // 按级别配置采样, 如 error: {initial: 10, thereafter: 100}
+	Dedup      map[string]int         `mapstructure:"dedup"`    // 按级别配置去重的时间窗口, 单位是秒, 如 error: 5
+	Syslog     logSyslog              `mapstructure:"syslog"`
+	Tcp        logTcp                 `mapstructure:"tcp"`
+	Ring       logRing                `mapstructure:"ring"`
+}
+
+// logSyslog 以 RFC5424 格式发送到 syslog
+type logSyslog struct {
+	Network    string `mapstructure:"network"`    // udp 或 tcp, 默认 udp
+	Addr       string `mapstructure:"addr"`       // 如 127.0.0.1:514
+	Tag        string `mapstructure:"tag"`        // APP-NAME, 默认为 App.Name
+	Facility   int    `mapstructure:"facility"`   // 默认 16, 即 local0
+	BufferSize int    `mapstructure:"bufferSize"` // 缓冲的日志条数, 超出时丢弃, 默认 10000
+}
+
+// logTcp 以换行分隔的 JSON 发送到 TCP 日志收集器, 断开时自动重连
+type logTcp struct {
+	Addr       string `mapstructure:"addr"`
+	BufferSize int    `mapstructure:"bufferSize"` // 缓冲的日志条数, 超出时丢弃, 默认 10000
+}
+
+// logRing 在内存中保留最近的日志, 可以通过管理接口查看
+type logRing struct {
+	Size int `mapstructure:"size"` // 保留的日志条数, 默认 1000
+}
+
+// logSampling 每秒内相同消息的前 Initial 条全部输出, 之后每 Thereafter 条输出一条
+type logSampling struct {
+	Initial    int `mapstructure:"initial"`
+	Thereafter int `mapstructure:"thereafter"`
 }
 type casbin struct {
 	ModePath string `mapstructure:"modePath"`
+	DbName   string `mapstructure:"dbName"`
 }
 type jwt struct {
 	SecretKey string `mapstructure:"secretKey"`
@@ -72,34 +161,204 @@ type oss struct {
 	Url        string `mapstructure:"url"`
 	AccessKey  string `mapstructure:"accessKey"`
 	SecretKey  string `mapstructure:"secretKey"`
-	BucketName string `mapstructure:"bucketName"`
+	Bucket     string `mapstructure:"bucket"`     // 已废弃, 使用 bucketName, 为兼容旧配置, bucketName 为空时仍然读取
+	BucketName string `mapstructure:"bucketName"` // 七牛云存储使用的空间名称
 }
 
-func LoadConfig[T any](file string, env string, target *T) error {
-	viper.SetConfigFile(file)
-	if err := viper.ReadInConfig(); err != nil {
-		return fmt.Errorf("读取配置文件错误: %s", err)
-	}
+// redact 日志脱敏, 自定义的规则会追加在内置规则 gzredact.DefaultRules 之后
+type redact struct {
+	Disable bool            `mapstructure:"disable"`
+	Rules   []gzredact.Rule `mapstructure:"rules"`
+}
+
+// audit 请求审计日志, 由 gzmiddleware.RequestLog 采集后异步批量写入
+type audit struct {
+	Enable        bool       `mapstructure:"enable"`
+	Sinks         []string   `mapstructure:"sinks"`         // 输出, 可选 log、gorm、mongo 以及通过 gzmiddleware.RegisterAuditSink 注册的输出
+	QueueSize     int        `mapstructure:"queueSize"`     // 队列长度, 队列满时丢弃新的记录, 默认 10000
+	BatchSize     int        `mapstructure:"batchSize"`     // 每批写入的条数, 默认 100
+	FlushInterval int        `mapstructure:"flushInterval"` // 不满一批时的最长等待时间, 单位是秒, 默认 1
+	Methods       []string   `mapstructure:"methods"`       // 只记录这些请求方法, 为空时记录全部
+	IncludePaths  []string   `mapstructure:"includePaths"`  // 只记录这些路径, 支持 * 通配, 为空时记录全部
+	ExcludePaths  []string   `mapstructure:"excludePaths"`  // 不记录这些路径, 支持 * 通配
+	MaxBodySize   int        `mapstructure:"maxBodySize"`   // 请求和响应超过该大小(字节)时不记录内容, 默认 65536
+	Gorm          auditGorm  `mapstructure:"gorm"`
+	Mongo         auditMongo `mapstructure:"mongo"`
+}
+
+type auditGorm struct {
+	DbName      string `mapstructure:"dbName"` // databases 中的数据库名称, 默认 default
+	Table       string `mapstructure:"table"`  // 默认 request_logs
+	AutoMigrate bool   `mapstructure:"autoMigrate"`
+}
+
+type auditMongo struct {
+	Database   string `mapstructure:"database"`
+	Collection string `mapstructure:"collection"` // 默认 request_logs
+}
+
+// ipConf 客户端 IP 相关的配置
+type ipConf struct {
+	GeoDb          string   `mapstructure:"geoDb"`          // ip2region 的 xdb 文件路径, 配置后 RequestLog 会记录 IP 归属地
+	TrustedProxies []string `mapstructure:"trustedProxies"` // 可信代理的 IP 或 CIDR, 只有来自可信代理的请求才会读取请求头中的 IP, 默认只信任本机
+	Headers        []string `mapstructure:"headers"`        // 按顺序读取的请求头, 默认 Forwarded、X-Forwarded-For、X-Real-IP, 使用 CDN 时可加入 CF-Connecting-IP 等
+}
+
+// traceConf 链路追踪, 需要导入 tracemodule 模块
+type traceConf struct {
+	Enable      bool              `mapstructure:"enable"`
+	ServiceName string            `mapstructure:"serviceName"` // 默认使用 app.name
+	Exporter    string            `mapstructure:"exporter"`    // otlp(默认)、stdout、file
+	Endpoint    string            `mapstructure:"endpoint"`    // OTLP HTTP 地址, 如 localhost:4318 或 https://collector/v1/traces, 为空时读取 OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure    bool              `mapstructure:"insecure"`    // 使用 http 连接 OTLP
+	Headers     map[string]string `mapstructure:"headers"`     // OTLP 请求头, 如鉴权信息
+	File        string            `mapstructure:"file"`        // exporter 为 file 时的文件路径
+	SampleRatio float64           `mapstructure:"sampleRatio"` // 采样率 0-1, 默认 1, 上游已采样的请求始终采样
+}
+
+// metricsConf Prometheus 指标, 需要导入 metricsmodule 模块
+type metricsConf struct {
+	Addr    string    `mapstructure:"addr"`    // 单独监听的地址, 如 :9100, 为空时需要自行把 metricsmodule.Handler() 挂载到路由上
+	Path    string    `mapstructure:"path"`    // 默认 /metrics
+	Buckets []float64 `mapstructure:"buckets"` // 请求耗时直方图的分桶(秒), 默认使用 prometheus.DefBuckets
+}
+
+// healthConf 健康检查
+type healthConf struct {
+	Timeout  int `mapstructure:"timeout"`  // 单个检查的超时时间(秒), 默认 3
+	CacheTtl int `mapstructure:"cacheTtl"` // 检查结果的缓存时间(毫秒), 默认 1000, 小于 0 时不缓存
+}
+
+// admin 管理接口的访问控制, Token 和 AllowIps 都为空时只允许本机访问
+type admin struct {
+	Token    string   `mapstructure:"token"`
+	AllowIps []string `mapstructure:"allowIps"`
+	Addr     string   `mapstructure:"addr"` // 管理服务单独监听的地址, 如 127.0.0.1:9090, 需要导入 adminmodule 模块, 为空时不启动
+}
+
+var (
+	defaultsMu     sync.RWMutex
+	configDefaults = make(map[string]interface{})
+)
+
+// SetConfigDefault 设置配置项的默认值, 与 viper.SetDefault 相同, 但热重载时也会应用到新的配置上, 应在 init 中调用
+func SetConfigDefault(key string, value interface{}) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
 
+	configDefaults[key] = value
+	viper.SetDefault(key, value)
+}
+
+// LoadConfig 读取配置文件, 如果存在与 App.Env 对应的环境配置文件(如 config.prod.yaml), 会覆盖到基础配置之上
+// 配置中的字符串可以使用 ${env:NAME}、${file:/run/secrets/xxx} 引用环境变量和文件中的密钥
+func LoadConfig[T any](file string, env string, target *T) error {
 	if env != "" {
 		if err := godotenv.Load(env); err != nil {
 			return fmt.Errorf("读取环境变量错误: %s", err)
 		}
 	}
 
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	layers, err := readConfigLayers(file)
+	if err != nil {
+		return err
+	}
+	if err = applyConfigLayers(viper.GetViper(), layers); err != nil {
+		return err
+	}
+
+	return viper.Unmarshal(target)
+}
+
+// configLayer 一层配置文件及其内容
+type configLayer struct {
+	File string
+	Data []byte
+}
+
+// readConfigLayers 读取基础配置文件, 以及根据 App.Env 选择的环境配置文件
+func readConfigLayers(file string) ([]configLayer, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件错误: %s", err)
+	}
+	layers := []configLayer{{File: file, Data: data}}
+
+	// 环境变量 APP_ENV 的优先级高于配置文件中的 app.env
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err = v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("读取配置文件错误: %s", err)
+	}
+	setupViper(v)
+	appEnv := v.GetString("App.Env")
+	if appEnv == "" {
+		return layers, nil
+	}
+
+	envFile := envConfigFile(file, appEnv)
+	data, err = os.ReadFile(envFile)
+	if os.IsNotExist(err) {
+		return layers, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取环境配置文件错误: %s", err)
+	}
+
+	return append(layers, configLayer{File: envFile, Data: data}), nil
+}
+
+// envConfigFile 返回环境配置文件的路径, 例如 conf/config.yaml 在 prod 环境下为 conf/config.prod.yaml
+func envConfigFile(file, appEnv string) string {
+	ext := filepath.Ext(file)
+
+	return strings.TrimSuffix(file, ext) + "." + appEnv + ext
+}
+
+// applyConfigLayers 将配置文件逐层合并到 v 中, 并解析其中的密钥引用
+func applyConfigLayers(v *viper.Viper, layers []configLayer) error {
+	defaultsMu.RLock()
+	for key, value := range configDefaults {
+		v.SetDefault(key, value)
+	}
+	defaultsMu.RUnlock()
+
+	v.SetConfigFile(layers[0].File)
+	if err := v.ReadConfig(bytes.NewReader(layers[0].Data)); err != nil {
+		return fmt.Errorf("读取配置文件错误: %s", err)
+	}
+	for _, layer := range layers[1:] {
+		if err := v.MergeConfig(bytes.NewReader(layer.Data)); err != nil {
+			return fmt.Errorf("读取环境配置文件 %s 错误: %s", layer.File, err)
+		}
+	}
+
+	if err := resolveSecrets(v); err != nil {
+		return err
+	}
+	setupViper(v)
+
+	return nil
+}
+
+// setupViper 开启环境变量覆盖, 并强制初始化必要的配置, 返回被强制设置的配置项
+func setupViper(v *viper.Viper) (forced []string) {
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	// 强制初始化必要的配置
-	if viper.GetInt("App.CacheCap") == 0 {
-		viper.Set("App.CacheCap", 100000)
+	if v.GetInt("App.CacheCap") == 0 {
+		v.Set("App.CacheCap", 100000)
+		forced = append(forced, "app.cachecap")
 	}
-	if viper.GetInt("App.CacheShard") == 0 {
-		viper.Set("App.CacheShard", 64)
+	if v.GetInt("App.CacheShard") == 0 {
+		v.Set("App.CacheShard", 64)
+		forced = append(forced, "app.cacheshard")
 	}
-	if viper.GetString("Casbin.DbName") == "" {
-		viper.Set("Casbin.DbName", "default")
+	if v.GetString("Casbin.DbName") == "" {
+		v.Set("Casbin.DbName", "default")
+		forced = append(forced, "casbin.dbname")
 	}
 
-	return viper.Unmarshal(target)
+	return forced
 }
@@ -0,0 +1,196 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+)
+
+// ConfigSubscriber 配置变更的回调, old 和 new 分别为变更前后的完整配置
+type ConfigSubscriber func(old, new *BaseConfig)
+
+var (
+	currentConfig atomic.Pointer[BaseConfig]
+	reloadLock    sync.Mutex
+	// loadedSettings 当前配置的全部键值, 用于对比发生变化的配置段, 由 reloadLock 保护
+	loadedSettings map[string]interface{}
+
+	subscriberMu sync.RWMutex
+	subscribers  = make(map[string][]ConfigSubscriber)
+)
+
+// GetConfig 返回当前生效的配置, 可以在任意协程中调用, 热重载后返回新的配置
+func GetConfig() *BaseConfig {
+	if conf := currentConfig.Load(); conf != nil {
+		return conf
+	}
+
+	return Config
+}
+
+// OnConfigChange 订阅某个配置段的变更, section 为配置文件中的顶层键名(不区分大小写), 例如 `log`、`jwt`
+func OnConfigChange(section string, fn ConfigSubscriber) {
+	subscriberMu.Lock()
+	defer subscriberMu.Unlock()
+
+	section = strings.ToLower(section)
+	subscribers[section] = append(subscribers[section], fn)
+}
+
+// ReloadConfig 重新读取配置文件, 校验通过后原子替换当前配置, 并通知变更配置段的订阅者
+// 全局的 viper 和 base.Config 保持启动时的值, 需要感知热重载的代码应读取 GetConfig() 或订阅 OnConfigChange
+func ReloadConfig() error {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	layers, err := readConfigLayers(viper.ConfigFileUsed())
+	if err != nil {
+		return err
+	}
+
+	// 1. 先用独立的 viper 实例解析和校验, 失败时不影响当前配置
+	next, settings, err := parseConfig(layers)
+	if err != nil {
+		return err
+	}
+
+	// 2. 校验通过后原子替换 GetConfig 返回的配置, 不修改全局的 viper, 避免与请求中的读取并发读写
+	before := loadedSettings
+	if before == nil {
+		before = viper.AllSettings()
+	}
+	old := GetConfig()
+	currentConfig.Store(next)
+	loadedSettings = settings
+
+	// 3. 通知订阅者
+	sections := changedSections(before, settings)
+	if len(sections) == 0 {
+		gzconsole.Echo.Info("ℹ️ 提示: 配置文件已重新读取, 没有发生变化\n")
+		return nil
+	}
+	gzconsole.Echo.Infof("✅  提示: 配置已重载, 发生变化的配置段: %v\n", sections)
+	notifySubscribers(sections, old, next)
+
+	return nil
+}
+
+// parseConfig 用独立的 viper 实例解析配置文件, 返回解析后的配置和全部键值
+func parseConfig(layers []configLayer) (*BaseConfig, map[string]interface{}, error) {
+	v := viper.New()
+	if err := applyConfigLayers(v, layers); err != nil {
+		return nil, nil, err
+	}
+
+	next := new(BaseConfig)
+	if err := v.Unmarshal(next); err != nil {
+		return nil, nil, fmt.Errorf("解析配置文件错误: %s", err)
+	}
+	if issues := ValidateConfig(next); issues.HasError() && !isDevEnv(next.App.Env) {
+		return nil, nil, fmt.Errorf("配置校验未通过:\n%s", issues.Table())
+	}
+
+	return next, v.AllSettings(), nil
+}
+
+// changedSections 对比前后两份配置, 返回发生变化的顶层配置段
+func changedSections(before, after map[string]interface{}) []string {
+	var sections []string
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			sections = append(sections, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			sections = append(sections, key)
+		}
+	}
+	sort.Strings(sections)
+
+	return sections
+}
+
+func notifySubscribers(sections []string, old, next *BaseConfig) {
+	subscriberMu.RLock()
+	defer subscriberMu.RUnlock()
+
+	for _, section := range sections {
+		for _, fn := range subscribers[section] {
+			gzutil.RunSafe(func() {
+				fn(old, next)
+			})
+		}
+	}
+}
+
+// watchConfig 监听配置文件(包括环境配置文件)的变化以及 SIGHUP 信号, 自动重载配置
+// 监听的是配置文件所在的目录, 这样编辑器的原子保存和 k8s ConfigMap 的软链接替换都可以被感知
+func watchConfig() error {
+	file := filepath.Clean(viper.ConfigFileUsed())
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建配置文件监听失败: %s", err)
+	}
+	if err = watcher.Add(filepath.Dir(file)); err != nil {
+		_ = watcher.Close()
+		return fmt.Errorf("监听配置文件失败: %s", err)
+	}
+
+	reload := func() {
+		if err := ReloadConfig(); err != nil {
+			gzconsole.Echo.Warnf("⚠️  警告: 配置重载失败, 继续使用原配置: %s\n", err)
+		}
+	}
+	OnReload(reload)
+
+	realFile, _ := filepath.EvalSymlinks(file)
+	gzutil.SafeGo(func() {
+		// 保存文件时通常会触发多个事件, 合并 200ms 内的事件只重载一次
+		var debounce *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				currentFile, _ := filepath.EvalSymlinks(file)
+				linkChanged := currentFile != "" && currentFile != realFile
+				realFile = currentFile
+				name := filepath.Clean(event.Name)
+				isConfigFile := name == file || name == filepath.Clean(envConfigFile(file, GetConfig().App.Env))
+				fileChanged := isConfigFile && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Remove))
+				if !fileChanged && !linkChanged {
+					continue
+				}
+				if debounce != nil {
+					debounce.Stop()
+				}
+				debounce = time.AfterFunc(200*time.Millisecond, reload)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				gzconsole.Echo.Warnf("⚠️  警告: 配置文件监听异常: %s\n", err)
+			}
+		}
+	})
+
+	gzconsole.RegisterStop("config-watcher", func(ctx context.Context) error {
+		return watcher.Close()
+	})
+	gzconsole.Echo.Infof("✅  提示: 已开启配置热重载, 正在监听: %s\n", file)
+
+	return nil
+}
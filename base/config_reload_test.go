@@ -0,0 +1,79 @@
+package base
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"go.uber.org/zap"
+)
+
+// useConfig 替换 GetConfig 返回的配置, 测试结束后恢复
+func useConfig(t *testing.T, conf *BaseConfig) {
+	prev := currentConfig.Load()
+	currentConfig.Store(conf)
+	t.Cleanup(func() {
+		currentConfig.Store(prev)
+	})
+}
+
+func TestReloadConfigKeepsGlobalViper(t *testing.T) {
+	if gzconsole.Echo == nil {
+		gzconsole.Echo = zap.NewNop().Sugar()
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	data := []byte("app:\n  env: dev\n  routerPrefix: /v1\n")
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	v := viper.GetViper()
+	if err := applyConfigLayers(v, []configLayer{{File: file, Data: data}}); err != nil {
+		t.Fatal(err)
+	}
+	startup := new(BaseConfig)
+	if err := v.Unmarshal(startup); err != nil {
+		t.Fatal(err)
+	}
+	useConfig(t, startup)
+	prevSettings := loadedSettings
+	t.Cleanup(func() {
+		loadedSettings = prevSettings
+	})
+
+	var notified *BaseConfig
+	OnConfigChange("app", func(_, next *BaseConfig) {
+		notified = next
+	})
+
+	// 热重载与请求中读取全局 viper 并发进行
+	if err := os.WriteFile(file, []byte("app:\n  env: dev\n  routerPrefix: /v2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = viper.GetString("App.RouterPrefix")
+		}
+	}()
+	err := ReloadConfig()
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := GetConfig().App.RouterPrefix; got != "/v2" || notified != GetConfig() {
+		t.Fatalf("GetConfig().App.RouterPrefix = %q", got)
+	}
+	if got := viper.GetString("App.RouterPrefix"); got != "/v1" {
+		t.Fatalf("全局 viper 不应被修改, App.RouterPrefix = %q", got)
+	}
+	// 通过 SetConfigDefault 设置的默认值同样应用到热重载后的配置
+	if GetConfig().Logger.Level != "debug" || len(GetConfig().Ip.TrustedProxies) == 0 {
+		t.Fatalf("热重载后缺少默认值: %+v", GetConfig().Logger)
+	}
+}
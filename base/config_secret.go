@@ -0,0 +1,98 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// secretPattern 匹配配置中的密钥引用, 支持:
+//   - ${env:NAME}           读取环境变量, 未设置时报错
+//   - ${env:NAME:-default}  读取环境变量, 未设置时使用默认值
+//   - ${file:/path/to/file} 读取文件内容, 会去掉末尾的换行, 适用于 docker/k8s secrets
+var secretPattern = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+
+// resolveSecrets 解析配置中所有字符串里的密钥引用, 解析后的值只存在于内存中
+func resolveSecrets(v *viper.Viper) error {
+	var errs []error
+	patch := make(map[string]interface{})
+	for key, value := range v.AllSettings() {
+		resolved, changed := resolveSecretValue(key, value, &errs)
+		if changed {
+			patch[key] = resolved
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("解析配置中的密钥引用失败: %w", errors.Join(errs...))
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+
+	return v.MergeConfigMap(patch)
+}
+
+// resolveSecretValue 递归解析 map、slice 中的字符串, 返回解析后的值以及是否发生了替换
+func resolveSecretValue(path string, value interface{}, errs *[]error) (interface{}, bool) {
+	switch val := value.(type) {
+	case string:
+		if !strings.Contains(val, "${") {
+			return val, false
+		}
+		resolved := secretPattern.ReplaceAllStringFunc(val, func(match string) string {
+			secret, err := lookupSecret(secretPattern.FindStringSubmatch(match))
+			if err != nil {
+				*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+			}
+			return secret
+		})
+		return resolved, resolved != val
+	case map[string]interface{}:
+		changed := false
+		result := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			resolved, itemChanged := resolveSecretValue(path+"."+k, item, errs)
+			result[k] = resolved
+			changed = changed || itemChanged
+		}
+		return result, changed
+	case []interface{}:
+		changed := false
+		result := make([]interface{}, len(val))
+		for i, item := range val {
+			resolved, itemChanged := resolveSecretValue(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			result[i] = resolved
+			changed = changed || itemChanged
+		}
+		return result, changed
+	default:
+		return value, false
+	}
+}
+
+func lookupSecret(match []string) (string, error) {
+	kind, ref := match[1], strings.TrimSpace(match[2])
+	switch kind {
+	case "env":
+		name, defaultValue, hasDefault := strings.Cut(ref, ":-")
+		if value, ok := os.LookupEnv(name); ok {
+			return value, nil
+		}
+		if hasDefault {
+			return defaultValue, nil
+		}
+		return "", fmt.Errorf("环境变量 %s 未设置", name)
+	case "file":
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return "", fmt.Errorf("读取密钥文件失败: %s", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	return "", fmt.Errorf("不支持的引用类型 %s", kind)
+}
@@ -0,0 +1,76 @@
+package base
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("GZ_TEST_DB_PASS", "s3cret")
+	file := filepath.Join(t.TempDir(), "jwt_key")
+	if err := os.WriteFile(file, []byte("file-secret\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v := viper.New()
+	err := v.MergeConfigMap(map[string]interface{}{
+		"mysql": map[string]interface{}{
+			"dsn":  "root:${env:GZ_TEST_DB_PASS}@tcp(127.0.0.1:3306)/app",
+			"port": 3306,
+		},
+		"jwt":   map[string]interface{}{"secretKey": "${file:" + file + "}"},
+		"redis": map[string]interface{}{"addrs": []interface{}{"${env:GZ_TEST_UNSET_ADDR:-127.0.0.1:6379}", "plain"}},
+		"app":   map[string]interface{}{"name": "${unknown:NAME}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = resolveSecrets(v); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]interface{}{
+		"mysql.dsn":     "root:s3cret@tcp(127.0.0.1:3306)/app",
+		"mysql.port":    3306,
+		"jwt.secretKey": "file-secret",
+		"app.name":      "${unknown:NAME}",
+	}
+	for key, want := range cases {
+		if got := v.Get(key); got != want {
+			t.Errorf("%s = %v, 期望 %v", key, got, want)
+		}
+	}
+	if got := v.GetStringSlice("redis.addrs"); len(got) != 2 || got[0] != "127.0.0.1:6379" || got[1] != "plain" {
+		t.Errorf("redis.addrs = %v", got)
+	}
+}
+
+func TestResolveSecretsErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	v := viper.New()
+	err := v.MergeConfigMap(map[string]interface{}{
+		"mysql": map[string]interface{}{"dsn": "root:${env:GZ_TEST_UNSET_PASS}@tcp(127.0.0.1)/app"},
+		"jwt":   map[string]interface{}{"secretKey": "${file:" + missing + "}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = resolveSecrets(v)
+	if err == nil {
+		t.Fatal("引用不存在时应返回错误")
+	}
+	for _, want := range []string{"mysql.dsn: 环境变量 GZ_TEST_UNSET_PASS 未设置", "jwt.secretkey: 读取密钥文件失败"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误 %q 中缺少 %q", err, want)
+		}
+	}
+	// 解析失败时不修改原配置
+	if got := v.GetString("mysql.dsn"); !strings.Contains(got, "${env:GZ_TEST_UNSET_PASS}") {
+		t.Errorf("mysql.dsn = %q", got)
+	}
+}
@@ -0,0 +1,294 @@
+package base
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzredact"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	IssueError = "错误"
+	IssueWarn  = "警告"
+)
+
+// ConfigIssue 配置校验发现的一个问题
+type ConfigIssue struct {
+	Level string
+	Key   string
+	Msg   string
+}
+
+type ConfigIssues []ConfigIssue
+
+// ConfigValidator 配置校验函数, 模块可以在 init 中注册, 只有导入了该模块才会执行对应的校验
+type ConfigValidator func(conf *BaseConfig, issues *ConfigIssues)
+
+var (
+	validatorMu sync.Mutex
+	validators  = []ConfigValidator{validateApp, validateLog, validateJwt, validateOss, validateAdmin, validateRedact, validateIp}
+)
+
+// RegisterConfigValidator 注册配置校验函数
+func RegisterConfigValidator(fn ConfigValidator) {
+	validatorMu.Lock()
+	defer validatorMu.Unlock()
+	validators = append(validators, fn)
+}
+
+// ValidateConfig 执行所有配置校验, 一次性返回发现的全部问题
+func ValidateConfig(conf *BaseConfig) ConfigIssues {
+	validatorMu.Lock()
+	fns := append([]ConfigValidator{}, validators...)
+	validatorMu.Unlock()
+
+	issues := ConfigIssues{}
+	for _, fn := range fns {
+		fn(conf, &issues)
+	}
+
+	return issues
+}
+
+func (issues *ConfigIssues) AddError(key, format string, args ...interface{}) {
+	*issues = append(*issues, ConfigIssue{Level: IssueError, Key: key, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (issues *ConfigIssues) AddWarn(key, format string, args ...interface{}) {
+	*issues = append(*issues, ConfigIssue{Level: IssueWarn, Key: key, Msg: fmt.Sprintf(format, args...)})
+}
+
+// HasError 是否存在严重问题
+func (issues ConfigIssues) HasError() bool {
+	for _, issue := range issues {
+		if issue.Level == IssueError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Table 以表格的形式输出所有问题
+func (issues ConfigIssues) Table() string {
+	rows := [][]string{{"级别", "配置项", "问题"}}
+	for _, issue := range issues {
+		rows = append(rows, []string{issue.Level, issue.Key, issue.Msg})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+
+	var builder strings.Builder
+	line := func() {
+		builder.WriteString("+")
+		for _, width := range widths {
+			builder.WriteString(strings.Repeat("-", width+2) + "+")
+		}
+		builder.WriteString("\n")
+	}
+
+	line()
+	for i, row := range rows {
+		builder.WriteString("|")
+		for j, cell := range row {
+			builder.WriteString(" " + cell + strings.Repeat(" ", widths[j]-displayWidth(cell)) + " |")
+		}
+		builder.WriteString("\n")
+		if i == 0 {
+			line()
+		}
+	}
+	line()
+
+	return builder.String()
+}
+
+// displayWidth 计算字符串在终端中的显示宽度, 中文等宽字符占两列
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) || (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+
+	return width
+}
+
+// checkConfig 校验配置并输出问题列表, 非开发环境下存在严重问题时拒绝继续
+func checkConfig(conf *BaseConfig) error {
+	issues := ValidateConfig(conf)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	if issues.HasError() && !isDevEnv(conf.App.Env) {
+		return fmt.Errorf("配置校验未通过, 请修正以下问题:\n%s", issues.Table())
+	}
+	gzconsole.Echo.Warnf("⚠️  警告: 配置校验发现以下问题:\n%s", issues.Table())
+
+	return nil
+}
+
+func isDevEnv(env string) bool {
+	return gzutil.InArray(env, []string{"dev", "local", "debug", "test"})
+}
+
+func validateApp(conf *BaseConfig, issues *ConfigIssues) {
+	app := conf.App
+	if app.Env == "" {
+		issues.AddWarn("app.env", "未设置运行环境, 将按照生产环境处理")
+	}
+	if app.Addr == "" {
+		issues.AddWarn("app.addr", "未设置服务监听地址")
+	} else if _, _, err := net.SplitHostPort(app.Addr); err != nil {
+		issues.AddError("app.addr", "监听地址 %q 格式错误, 应为 host:port 或 :port", app.Addr)
+	}
+	if app.Timeout < 0 {
+		issues.AddError("app.timeout", "不能小于 0")
+	}
+	if app.ShutdownTimeout < 0 {
+		issues.AddError("app.shutdownTimeout", "不能小于 0")
+	}
+	if app.CacheShard > 0 && app.CacheShard&(app.CacheShard-1) != 0 {
+		issues.AddWarn("app.cacheShard", "%d 不是 2 的幂, 将使用默认的分片数量", app.CacheShard)
+	}
+}
+
+func validateLog(conf *BaseConfig, issues *ConfigIssues) {
+	if conf.Logger.Level != "" {
+		if _, err := zapcore.ParseLevel(conf.Logger.Level); err != nil {
+			issues.AddError("log.level", "无效的日志级别 %q, 可选值: debug、info、warn、error、fatal", conf.Logger.Level)
+		}
+	}
+
+	if layout := conf.Logger.Layout; layout != "" && layout != logLayoutLevel && layout != logLayoutSingle {
+		issues.AddError("log.layout", "不支持的文件布局 %q, 可选值: %s、%s", layout, logLayoutLevel, logLayoutSingle)
+	}
+	if conf.Logger.ErrorFile && conf.Logger.Layout != logLayoutSingle {
+		issues.AddWarn("log.errorFile", "只在 single 布局下生效")
+	}
+
+	for _, mode := range logModes(conf.Logger.Mode) {
+		if !hasLogSink(mode) {
+			issues.AddError("log.mode", "未知的日志输出 %q", mode)
+			continue
+		}
+		switch mode {
+		case "syslog":
+			if conf.Logger.Syslog.Addr == "" {
+				issues.AddError("log.syslog.addr", "开启 syslog 输出时必须配置")
+			}
+			if network := conf.Logger.Syslog.Network; network != "" && network != "udp" && network != "tcp" {
+				issues.AddError("log.syslog.network", "不支持的协议 %q, 可选值: udp、tcp", network)
+			}
+		case "tcp":
+			if conf.Logger.Tcp.Addr == "" {
+				issues.AddError("log.tcp.addr", "开启 tcp 输出时必须配置")
+			}
+		}
+	}
+
+	for level, sampling := range conf.Logger.Sampling {
+		key := "log.sampling." + level
+		if _, err := zapcore.ParseLevel(level); err != nil {
+			issues.AddError(key, "无效的日志级别 %q", level)
+		}
+		if sampling.Initial <= 0 {
+			issues.AddWarn(key+".initial", "未设置或小于等于 0, 该级别不会进行采样")
+		}
+		if sampling.Thereafter < 0 {
+			issues.AddError(key+".thereafter", "不能小于 0")
+		}
+	}
+	for level, window := range conf.Logger.Dedup {
+		key := "log.dedup." + level
+		if _, err := zapcore.ParseLevel(level); err != nil {
+			issues.AddError(key, "无效的日志级别 %q", level)
+		}
+		if window < 0 {
+			issues.AddError(key, "去重的时间窗口不能小于 0")
+		}
+	}
+}
+
+func validateJwt(conf *BaseConfig, issues *ConfigIssues) {
+	if conf.Jwt.SecretKey == "" {
+		issues.AddError("jwt.secretKey", "为空时会使用固定密钥 1234567890, Token 可以被任意伪造")
+	} else if len(conf.Jwt.SecretKey) < 16 {
+		issues.AddWarn("jwt.secretKey", "长度小于 16, 建议使用更长的随机字符串")
+	}
+	if conf.Jwt.Expire < 0 {
+		issues.AddError("jwt.expire", "不能小于 0")
+	} else if conf.Jwt.Expire == 0 {
+		issues.AddWarn("jwt.expire", "未设置, 将使用默认值 20 分钟")
+	}
+}
+
+func validateOss(conf *BaseConfig, issues *ConfigIssues) {
+	switch conf.Oss.Type {
+	case "", "local":
+	case "qiniu":
+		required := map[string]string{
+			"oss.accessKey":  conf.Oss.AccessKey,
+			"oss.secretKey":  conf.Oss.SecretKey,
+			"oss.bucketName": gzutil.Ternary(conf.Oss.BucketName != "", conf.Oss.BucketName, conf.Oss.Bucket),
+			"oss.url":        conf.Oss.Url,
+		}
+		for _, key := range []string{"oss.accessKey", "oss.secretKey", "oss.bucketName", "oss.url"} {
+			if required[key] == "" {
+				issues.AddError(key, "使用七牛云存储时必须配置")
+			}
+		}
+		if conf.Oss.BucketName == "" && conf.Oss.Bucket != "" {
+			issues.AddWarn("oss.bucket", "已废弃, 请改为 oss.bucketName")
+		}
+	case "aliyun":
+		issues.AddWarn("oss.type", "aliyun 暂未实现, 将使用本地存储")
+	default:
+		issues.AddError("oss.type", "不支持的存储类型 %q, 可选值: local、qiniu、aliyun", conf.Oss.Type)
+	}
+}
+
+func validateAdmin(conf *BaseConfig, issues *ConfigIssues) {
+	for i, allow := range conf.Admin.AllowIps {
+		if _, _, err := net.ParseCIDR(allow); err != nil && net.ParseIP(allow) == nil {
+			issues.AddError(fmt.Sprintf("admin.allowIps[%d]", i), "%q 不是有效的 IP 或 CIDR", allow)
+		}
+	}
+	if conf.Admin.Token != "" && len(conf.Admin.Token) < 16 {
+		issues.AddWarn("admin.token", "长度小于 16, 建议使用更长的随机字符串")
+	}
+}
+
+func validateRedact(conf *BaseConfig, issues *ConfigIssues) {
+	if _, err := gzredact.New(conf.Redact.Rules); err != nil {
+		issues.AddError("redact.rules", "%s", err)
+	}
+}
+
+func validateIp(conf *BaseConfig, issues *ConfigIssues) {
+	if _, err := gzutil.ParseIPNets(conf.Ip.TrustedProxies); err != nil {
+		issues.AddError("ip.trustedProxies", "%s", err)
+	}
+	if conf.Ip.GeoDb == "" {
+		return
+	}
+	if _, err := os.Stat(conf.Ip.GeoDb); err != nil {
+		issues.AddWarn("ip.geoDb", "IP 归属地库不可用, 将不记录归属地: %s", err)
+	}
+}
@@ -17,6 +17,9 @@ import (
 var (
 	dbMap sync.Map
 
+	// Config 启动时读取的配置, 热重载后不会更新
+	//
+	// Deprecated: 使用 GetConfig() 读取当前生效的配置
 	Config *BaseConfig
 	Log    *iLog
 	Cache  *gzcache.CacheNode
@@ -66,3 +69,12 @@ func Sqlx(name ...string) *sqlx.DB {
 
 	return nil
 }
+
+// RangeDb 遍历已加载的数据库, fn 返回 false 时停止遍历
+// 只配置了一个数据库时, 它同时以自身的名称和 `default` 注册, 两者指向同一个连接池
+func RangeDb(fn func(name string, gdb *gorm.DB, sdb *sqlx.DB) bool) {
+	dbMap.Range(func(key, value any) bool {
+		ins := value.(*instance)
+		return fn(key.(string), ins.GORM, ins.SQLX)
+	})
+}
@@ -0,0 +1,187 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+)
+
+const (
+	HealthOk           = "ok"
+	HealthFail         = "fail"
+	HealthShuttingDown = "shutting_down"
+)
+
+// HealthChecker 组件的健康检查, 返回 nil 表示正常, 需要在 ctx 超时后尽快返回
+type HealthChecker func(ctx context.Context) error
+
+// HealthStatus 单个组件的检查结果
+type HealthStatus struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthReport 所有组件的检查结果, 任何一个组件失败时 Status 为 fail
+type HealthReport struct {
+	Status    string                  `json:"status"`
+	CheckedAt time.Time               `json:"checkedAt"`
+	Checks    map[string]HealthStatus `json:"checks,omitempty"`
+}
+
+type healthCheck struct {
+	name     string
+	fn       HealthChecker
+	liveness bool
+}
+
+type healthCache struct {
+	mu     sync.Mutex
+	report HealthReport
+}
+
+var (
+	healthMu     sync.RWMutex
+	healthChecks []healthCheck
+
+	livenessCache  healthCache
+	readinessCache healthCache
+)
+
+// RegisterHealthCheck 注册就绪检查, 用于 /readyz, 一般用于检查数据库、Redis 等依赖, name 相同时覆盖之前的检查
+//
+//	base.RegisterHealthCheck("db:default", func(ctx context.Context) error { return sqlDB.PingContext(ctx) })
+func RegisterHealthCheck(name string, fn HealthChecker) {
+	registerHealthCheck(healthCheck{name: name, fn: fn})
+}
+
+// RegisterLivenessCheck 注册存活检查, 用于 /livez, 只应检查进程自身是否还能正常工作(如死锁), 失败时进程会被重启
+func RegisterLivenessCheck(name string, fn HealthChecker) {
+	registerHealthCheck(healthCheck{name: name, fn: fn, liveness: true})
+}
+
+func registerHealthCheck(check healthCheck) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	for i, existing := range healthChecks {
+		if existing.name == check.name && existing.liveness == check.liveness {
+			healthChecks[i] = check
+			return
+		}
+	}
+	healthChecks = append(healthChecks, check)
+}
+
+// CheckHealth 并发执行所有检查, liveness 为 true 时只执行存活检查
+// 结果会缓存 health.cacheTtl 毫秒, 缓存期内的并发请求共用同一次检查, 避免探针把依赖压垮
+func CheckHealth(ctx context.Context, liveness bool) HealthReport {
+	cache := gzutil.Ternary(liveness, &livenessCache, &readinessCache)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	conf := GetConfig().Health
+	ttl := time.Duration(gzutil.Ternary(conf.CacheTtl == 0, 1000, conf.CacheTtl)) * time.Millisecond
+	if ttl > 0 && !cache.report.CheckedAt.IsZero() && time.Since(cache.report.CheckedAt) < ttl {
+		return cache.report
+	}
+
+	timeout := time.Duration(gzutil.Ternary(conf.Timeout <= 0, 3, conf.Timeout)) * time.Second
+	cache.report = runHealthChecks(ctx, liveness, timeout)
+
+	return cache.report
+}
+
+func runHealthChecks(ctx context.Context, liveness bool, timeout time.Duration) HealthReport {
+	healthMu.RLock()
+	var checks []healthCheck
+	for _, check := range healthChecks {
+		if check.liveness == liveness {
+			checks = append(checks, check)
+		}
+	}
+	healthMu.RUnlock()
+
+	report := HealthReport{Status: HealthOk, Checks: make(map[string]HealthStatus, len(checks))}
+	results := make([]HealthStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runHealthCheck(ctx, check, timeout)
+		}()
+	}
+	wg.Wait()
+
+	// 以检查完成的时间作为缓存的起点
+	report.CheckedAt = time.Now()
+	for i, check := range checks {
+		report.Checks[check.name] = results[i]
+		if results[i].Status != HealthOk {
+			report.Status = HealthFail
+		}
+	}
+
+	return report
+}
+
+// runHealthCheck 执行单个检查, 超时后不再等待, 避免某个检查卡住导致探针超时
+func runHealthCheck(ctx context.Context, check healthCheck, timeout time.Duration) HealthStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		err := fmt.Errorf("检查时发生 panic")
+		gzutil.RunSafe(func() {
+			err = check.fn(ctx)
+		})
+		done <- err
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("检查超时(%s)", timeout)
+	}
+
+	status := HealthStatus{Status: HealthOk, Duration: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		status.Status = HealthFail
+		status.Error = err.Error()
+	}
+
+	return status
+}
+
+// LivezHandler 存活探针, 正常时返回 200, 否则返回 503
+func LivezHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		writeHealthReport(ctx, CheckHealth(ctx.Request.Context(), true))
+	}
+}
+
+// ReadyzHandler 就绪探针, 所有依赖正常时返回 200, 否则返回 503, 进程开始停止后立即返回 503, 让负载均衡摘除流量
+func ReadyzHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if serviceCtx.Err() != nil {
+			writeHealthReport(ctx, HealthReport{Status: HealthShuttingDown, CheckedAt: time.Now()})
+			return
+		}
+		writeHealthReport(ctx, CheckHealth(ctx.Request.Context(), false))
+	}
+}
+
+func writeHealthReport(ctx *gin.Context, report HealthReport) {
+	code := gzutil.Ternary(report.Status == HealthOk, http.StatusOK, http.StatusServiceUnavailable)
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(code, report)
+}
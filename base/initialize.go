@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -10,7 +11,6 @@ import (
 	"github.com/spf13/viper"
 	"github.com/w01fb0ss/gin-starter/gzconsole"
 	"github.com/w01fb0ss/gin-starter/pkg/gzcache"
-	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -18,13 +18,16 @@ import (
 var configFile string
 var env string
 var show bool
+var watch bool
 
 func init() {
 	gzconsole.RootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file")
 	gzconsole.RootCmd.PersistentFlags().StringVar(&env, "env", "", "env file")
 	gzconsole.RootCmd.PersistentFlags().BoolVar(&show, "show", true, "Whether to display startup information")
+	gzconsole.RootCmd.PersistentFlags().BoolVar(&watch, "watch", false, "Reload config when the file changes or on SIGHUP")
 	gzconsole.RootCmd.CompletionOptions.DisableDefaultCmd = true
-	gzconsole.Register(-1, serviceMgrCmd)
+	gzconsole.RootCmd.SilenceUsage = true
+	gzconsole.Register(serviceMgrCmd, gzconsole.AllModules)
 	gzconsole.RootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		if configFile == "" {
 			gzconsole.Show(getCommands(), getGlobalFlags())
@@ -32,7 +35,8 @@ func init() {
 			return nil
 		}
 
-		if show {
+		// 工具类命令的输出可能会被重定向到文件, 不输出启动信息
+		if show && !isConfigOnly(cmd) {
 			gzconsole.Show(getCommands(), getGlobalFlags())
 		}
 
@@ -41,6 +45,7 @@ func init() {
 			gzconsole.Echo = initSugaredLogger("")
 			return err
 		}
+		currentConfig.Store(Config)
 
 		// 2. 初始化 Echo 输出
 		gzconsole.Echo = initSugaredLogger(Config.App.Env)
@@ -48,16 +53,47 @@ func init() {
 		// 3. 初始化日志
 		initILog()
 
-		// 4. 初始化缓存模块
+		// 工具类命令只需要读取配置, 不需要校验配置和初始化缓存等
+		if isConfigOnly(cmd) {
+			return nil
+		}
+
+		// 4. 校验配置, 一次性输出所有问题
+		if err := checkConfig(Config); err != nil {
+			return err
+		}
+
+		// 5. 初始化客户端 IP 的解析规则
+		initClientIP()
+
+		// 6. 初始化缓存模块
 		Cache = gzcache.New(viper.GetInt("App.CacheCap"), viper.GetInt("App.CacheShard"), time.Duration(viper.GetInt("App.CacheClear")))
+		gzconsole.RegisterStop("cache", func(ctx context.Context) error {
+			Cache.Close()
+			return nil
+		})
+
+		// 7. 开启配置热重载
+		if watch {
+			if err := watchConfig(); err != nil {
+				return err
+			}
+		}
 
 		return nil
 	}
 }
 
+// annotationConfigOnly 标记只需要读取配置的工具类命令, 如 config、loglevel
+const annotationConfigOnly = "configOnly"
+
+func isConfigOnly(cmd *cobra.Command) bool {
+	return cmd.Annotations[annotationConfigOnly] == "true"
+}
+
 func initSugaredLogger(env string) *zap.SugaredLogger {
 	config := zap.NewDevelopmentConfig()
-	if !gzutil.InArray(env, []string{"dev", "local", "debug", "test"}) {
+	if !isDevEnv(env) {
 		config.OutputPaths = []string{}
 	}
 	config.EncoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(time.DateTime)
@@ -2,54 +2,84 @@ package base
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"os"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
-	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var rotationSchedulerProcess *rotationScheduler
+var (
+	rotationSchedulerProcess *rotationScheduler
+
+	// rebuildMu 日志可能同时被热重载和零点轮转重建
+	rebuildMu sync.Mutex
+)
 
 type iLog struct {
 	*zap.Logger
 }
 
+func init() {
+	SetConfigDefault("Log.Level", "debug")
+	SetConfigDefault("Log.Path", "./logs")
+	SetConfigDefault("Log.Mode", "both")
+	SetConfigDefault("Log.Recover", false)
+	SetConfigDefault("Log.MaxSize", 100)
+	SetConfigDefault("Log.MaxBackups", 3)
+	SetConfigDefault("Log.MaxAge", 7)
+	SetConfigDefault("Log.Compress", true)
+	SetConfigDefault("Log.Logrotate", true)
+}
+
 func initILog() {
-	viper.SetDefault("Log.Path", "./logs")
-	viper.SetDefault("Log.Mode", "both")
-	viper.SetDefault("Log.Recover", false)
-	viper.SetDefault("Log.MaxSize", 100)
-	viper.SetDefault("Log.MaxBackups", 3)
-	viper.SetDefault("Log.MaxAge", 7)
-	viper.SetDefault("Log.Compress", true)
-	viper.SetDefault("Log.Logrotate", true)
+	logLevel.SetLevel(configLogLevel())
+	loadRedactor()
 	newILog()
 
-	// 日志轮转
-	if viper.GetBool("Log.Logrotate") || viper.GetBool("Log.Recover") {
+	// 配置热重载时重建日志, 并更新为配置文件中的级别
+	OnConfigChange("log", func(_, _ *BaseConfig) {
+		reloadLogLevel()
+		newILog()
+	})
+	OnConfigChange("redact", func(_, _ *BaseConfig) {
+		loadRedactor()
+	})
+
+	// 日志轮转, single 布局由写入器自己按日期切换文件, 不需要在零点重建日志
+	if conf := GetConfig().Logger; conf.Logrotate || conf.Recover {
 		rotationSchedulerProcess = newRotationScheduler(func() {
-			newILog()
+			if GetConfig().Logger.Layout != logLayoutSingle {
+				newILog()
+			}
 		})
 	}
 }
 
+// newILog 创建日志并替换 Log, 替换后刷新旧的日志并关闭不再使用的 sink
 func newILog() {
+	rebuildMu.Lock()
+	defer rebuildMu.Unlock()
+
+	beginLogSinks()
+	var owned []io.Closer
+	prev := Log
 	Log = &iLog{
 		Logger: zap.New(
-			getCore(),
+			&redactCore{Core: getCore(&owned)},
 			zap.AddCaller(),
 			zap.AddCallerSkip(0),
 			zap.AddStacktrace(zap.ErrorLevel),
 		),
 	}
+	if prev != nil {
+		_ = prev.Sync()
+	}
+	releaseLogSinks(owned)
 }
 
 func (l *iLog) With(fields ...zap.Field) *iLog {
@@ -58,105 +88,46 @@ func (l *iLog) With(fields ...zap.Field) *iLog {
 	}
 }
 
+// WithCtx 等同于 Ctx, 保留用于兼容
 func (l *iLog) WithCtx(ctx context.Context) *iLog {
-	var traceIdStr, sourceStr string
-	traceId := ctx.Value("trace_id")
-	if traceId != nil {
-		traceIdStr, _ = traceId.(string)
-	}
-
-	source := ctx.Value("source")
-	if source != nil {
-		sourceStr, _ = source.(string)
-	}
-
-	if traceIdStr != "" {
-		l.With(zap.String("trace_id", traceIdStr))
-	}
-	if sourceStr != "" {
-		l.With(zap.String("source", sourceStr))
-	}
-
-	return l
+	return l.Ctx(ctx)
 }
 
 // Core is a minimal, fast logger interface. It's designed for library authors
 // to wrap in a more user-friendly API.
 // only use infoLevel、errorLevel. want update can change == to > or >= or <= or <
-func getCore() zapcore.Core {
-	path := viper.GetString("Log.Path")
-	mode := viper.GetString("Log.Mode")
-	doRecover := viper.GetBool("Log.Recover")
-
+// 每个级别输出到各自的文件, 低于 logLevel 的日志会被丢弃, 并按照配置进行采样和去重
+func getCore(owned *[]io.Closer) zapcore.Core {
 	encoder := zapcore.NewJSONEncoder(getEncoderConfig())
-	debugWrite := getLogWriter(path, mode, doRecover, zapcore.DebugLevel)
-	infoWrite := getLogWriter(path, mode, doRecover, zapcore.InfoLevel)
-	warnWrite := getLogWriter(path, mode, doRecover, zapcore.WarnLevel)
-	errorWrite := getLogWriter(path, mode, doRecover, zapcore.ErrorLevel)
-	fatalWrite := getLogWriter(path, mode, doRecover, zapcore.FatalLevel)
+	debugWrite := getLogWriter(zapcore.DebugLevel, owned)
+	infoWrite := getLogWriter(zapcore.InfoLevel, owned)
+	warnWrite := getLogWriter(zapcore.WarnLevel, owned)
+	errorWrite := getLogWriter(zapcore.ErrorLevel, owned)
+	fatalWrite := getLogWriter(zapcore.FatalLevel, owned)
 	debugLevel := zap.LevelEnablerFunc(func(level zapcore.Level) bool {
-		return level == zapcore.DebugLevel
+		return level == zapcore.DebugLevel && logLevel.Enabled(level)
 	})
 	infoLevel := zap.LevelEnablerFunc(func(level zapcore.Level) bool {
-		return level == zapcore.InfoLevel
+		return level == zapcore.InfoLevel && logLevel.Enabled(level)
 	})
 	warnLevel := zap.LevelEnablerFunc(func(level zapcore.Level) bool {
-		return level == zapcore.WarnLevel
+		return level == zapcore.WarnLevel && logLevel.Enabled(level)
 	})
 	errorLevel := zap.LevelEnablerFunc(func(level zapcore.Level) bool {
-		return level == zapcore.ErrorLevel
+		return level == zapcore.ErrorLevel && logLevel.Enabled(level)
 	})
 	fatalLevel := zap.LevelEnablerFunc(func(level zapcore.Level) bool {
-		return level == zapcore.FatalLevel
+		return level == zapcore.FatalLevel && logLevel.Enabled(level)
 	})
 	return zapcore.NewTee(
-		zapcore.NewCore(encoder, zapcore.AddSync(debugWrite), debugLevel),
-		zapcore.NewCore(encoder, zapcore.AddSync(infoWrite), infoLevel),
-		zapcore.NewCore(encoder, zapcore.AddSync(warnWrite), warnLevel),
-		zapcore.NewCore(encoder, zapcore.AddSync(errorWrite), errorLevel),
-		zapcore.NewCore(encoder, zapcore.AddSync(fatalWrite), fatalLevel),
+		wrapLevelCore(zapcore.NewCore(encoder, zapcore.AddSync(debugWrite), debugLevel), zapcore.DebugLevel),
+		wrapLevelCore(zapcore.NewCore(encoder, zapcore.AddSync(infoWrite), infoLevel), zapcore.InfoLevel),
+		wrapLevelCore(zapcore.NewCore(encoder, zapcore.AddSync(warnWrite), warnLevel), zapcore.WarnLevel),
+		wrapLevelCore(zapcore.NewCore(encoder, zapcore.AddSync(errorWrite), errorLevel), zapcore.ErrorLevel),
+		wrapLevelCore(zapcore.NewCore(encoder, zapcore.AddSync(fatalWrite), fatalLevel), zapcore.FatalLevel),
 	)
 }
 
-// A WriteSyncer is an io.Writer that can also flush any buffered data. Note
-// that *os.File (and thus, os.Stderr and os.Stdout) implement WriteSyncer.
-func getLogWriter(path, mode string, recover bool, level zapcore.Level) zapcore.WriteSyncer {
-	maxSize := viper.GetInt("Log.MaxSize")
-	maxBackups := viper.GetInt("Log.MaxBackups")
-	maxAge := viper.GetInt("Log.MaxAge")
-	compress := viper.GetBool("Log.Compress")
-	if !strings.HasSuffix(path, "/") {
-		path += "/"
-	}
-	fileName := fmt.Sprintf("%s%s/%s.log", path, time.Now().Format("2006-01-02"), level)
-	var fileWriter io.Writer
-	if recover {
-		fileWriter = newCustomWrite(fileName, maxSize, maxBackups, maxAge, compress)
-	} else {
-		fileWriter = &lumberjack.Logger{
-			Filename:   fileName,
-			MaxSize:    maxSize,    // 单文件最大容量, 单位是MB
-			MaxBackups: maxBackups, // 最大保留过期文件个数
-			MaxAge:     maxAge,     // 保留过期文件的最大时间间隔, 单位是天
-			Compress:   compress,   // 是否需要压缩滚动日志, 使用的gzip压缩
-			LocalTime:  true,       // 是否使用计算机的本地时间, 默认UTC
-		}
-	}
-	var writer zapcore.WriteSyncer
-	switch mode {
-	case "file":
-		writer = zapcore.NewMultiWriteSyncer(zapcore.AddSync(fileWriter))
-	case "console":
-		writer = zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout))
-	case "close":
-		writer = zapcore.NewMultiWriteSyncer(zapcore.AddSync(io.Discard))
-	default:
-		writer = zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout), zapcore.AddSync(fileWriter))
-	}
-
-	return writer
-}
-
 // An EncoderConfig allows users to configure the concrete encoders supplied by zap core
 func getEncoderConfig() zapcore.EncoderConfig {
 	return zapcore.EncoderConfig{
@@ -305,9 +276,9 @@ func (cw *customWrite) Sync() error {
 	return cw.inner.Sync()
 }
 
-// Close 关闭写入器
+// Close 停止监控并关闭日志文件
 func (cw *customWrite) Close() error {
 	close(cw.done)
 
-	return cw.Sync()
+	return cw.logger.Close()
 }
@@ -0,0 +1,108 @@
+package base
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/w01fb0ss/gin-starter/pkg/gztrace"
+	"go.uber.org/zap"
+)
+
+// logFieldsKey 日志字段在 context 中的键, 使用私有类型避免与其他包的键冲突
+type logFieldsKey struct{}
+
+// LogExtractor 从 context 中提取日志字段, 没有可提取的内容时返回 nil
+type LogExtractor func(ctx context.Context) []zap.Field
+
+type namedExtractor struct {
+	name string
+	fn   LogExtractor
+}
+
+var (
+	extractorMu sync.RWMutex
+	extractors  []namedExtractor
+)
+
+func init() {
+	// 有链路信息时自动附加 trace_id 和 span_id, 与链路追踪系统中的记录对应
+	RegisterLogExtractor("trace", func(ctx context.Context) []zap.Field {
+		ctx = requestContext(ctx)
+		traceId := gztrace.TraceID(ctx)
+		if traceId == "" {
+			return nil
+		}
+
+		return []zap.Field{zap.String("trace_id", traceId), zap.String("span_id", gztrace.SpanID(ctx))}
+	})
+}
+
+// RegisterLogExtractor 注册日志字段提取器, name 相同时会覆盖之前注册的提取器
+func RegisterLogExtractor(name string, fn LogExtractor) {
+	extractorMu.Lock()
+	defer extractorMu.Unlock()
+
+	for i, extractor := range extractors {
+		if extractor.name == name {
+			extractors[i].fn = fn
+			return
+		}
+	}
+	extractors = append(extractors, namedExtractor{name: name, fn: fn})
+}
+
+// ContextWithLogFields 将日志字段附加到 context 中, 会保留 ctx 中已有的字段
+func ContextWithLogFields(ctx context.Context, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	existing, _ := requestContext(ctx).Value(logFieldsKey{}).([]zap.Field)
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	merged = append(merged, existing...)
+	merged = append(merged, fields...)
+
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+// LogFields 返回 context 中附加的日志字段, 以及所有提取器提取到的字段
+func LogFields(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := requestContext(ctx).Value(logFieldsKey{}).([]zap.Field)
+
+	extractorMu.RLock()
+	defer extractorMu.RUnlock()
+	for _, extractor := range extractors {
+		if extracted := extractor.fn(ctx); len(extracted) > 0 {
+			// 复制一份, 避免修改 context 中保存的切片
+			fields = append(fields[:len(fields):len(fields)], extracted...)
+		}
+	}
+
+	return fields
+}
+
+// requestContext *gin.Context 默认不会从 Request.Context() 中查找非字符串的键, 这里需要手动取出
+func requestContext(ctx context.Context) context.Context {
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		return c.Request.Context()
+	}
+
+	return ctx
+}
+
+// Ctx 返回附加了 context 中日志字段的 Logger, 没有任何字段时直接返回自身, 不会产生额外开销
+//
+//	base.Log.Ctx(ctx).Info("创建订单", zap.Int64("orderId", id))
+func (l *iLog) Ctx(ctx context.Context) *iLog {
+	fields := LogFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+
+	return l.With(fields...)
+}
@@ -0,0 +1,121 @@
+package base
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	logLayoutLevel  = "level"  // 每天一个目录, 每个级别一个文件, 如 logs/2006-01-02/info.log
+	logLayoutSingle = "single" // 每天一个文件, 所有级别写在一起, 如 logs/2006-01-02.log
+)
+
+// singleFileSink single 布局的文件输出, 所有级别共享同一个写入器
+// 开启 Log.ErrorFile 时, error 及以上级别会额外写入 logs/2006-01-02.error.log
+func singleFileSink(level zapcore.Level) (zapcore.WriteSyncer, error) {
+	logConf := GetConfig().Logger
+	conf := dailyWriterConf{
+		Path:       logConf.Path,
+		MaxSize:    logConf.MaxSize,
+		MaxBackups: logConf.MaxBackups,
+		MaxAge:     logConf.MaxAge,
+		Compress:   logConf.Compress,
+		Recover:    logConf.Recover,
+	}
+
+	all, err := sharedLogSink(fmt.Sprintf("file|%+v", conf), func() (*dailyWriter, error) {
+		return newDailyWriter(conf, ""), nil
+	})
+	if err != nil || level < zapcore.ErrorLevel || !logConf.ErrorFile {
+		return all, err
+	}
+
+	errorFile, err := sharedLogSink(fmt.Sprintf("file-error|%+v", conf), func() (*dailyWriter, error) {
+		return newDailyWriter(conf, ".error"), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return zapcore.NewMultiWriteSyncer(all, errorFile), nil
+}
+
+type dailyWriterConf struct {
+	Path       string
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+	Compress   bool
+	Recover    bool
+}
+
+// dailyWriter 按日期切换文件的写入器, 日期变化时在写入时切换, 同一天内由 lumberjack 按大小切割
+// 切换只发生在写入器内部, 不需要在零点重建 base.Log
+type dailyWriter struct {
+	mu        sync.Mutex
+	conf      dailyWriterConf
+	suffix    string
+	logger    *lumberjack.Logger
+	switchAt  time.Time // 下一次切换文件的时间, 即第二天零点
+	checkedAt time.Time
+}
+
+func newDailyWriter(conf dailyWriterConf, suffix string) *dailyWriter {
+	return &dailyWriter{conf: conf, suffix: suffix}
+}
+
+func (w *dailyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if !now.Before(w.switchAt) {
+		w.open(now)
+	} else if w.conf.Recover && now.Sub(w.checkedAt) > 30*time.Second {
+		// 文件被删除后重新创建
+		w.checkedAt = now
+		if _, err := os.Stat(w.logger.Filename); os.IsNotExist(err) {
+			w.open(now)
+		}
+	}
+
+	return w.logger.Write(p)
+}
+
+func (w *dailyWriter) open(now time.Time) {
+	if w.logger != nil {
+		_ = w.logger.Close()
+	}
+
+	w.logger = &lumberjack.Logger{
+		Filename:   filepath.Join(w.conf.Path, now.Format(time.DateOnly)+w.suffix+".log"),
+		MaxSize:    w.conf.MaxSize,
+		MaxBackups: w.conf.MaxBackups,
+		MaxAge:     w.conf.MaxAge,
+		Compress:   w.conf.Compress,
+		LocalTime:  true,
+	}
+	w.switchAt = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	w.checkedAt = now
+}
+
+func (w *dailyWriter) Sync() error {
+	return nil
+}
+
+func (w *dailyWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.logger == nil {
+		return nil
+	}
+
+	return w.logger.Close()
+}
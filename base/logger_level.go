@@ -0,0 +1,158 @@
+package base
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/w01fb0ss/gin-starter/pkg/gzerror"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// logLevel 日志的最低级别, 修改后立即对所有 Logger 生效
+	logLevel = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+
+	levelMu       sync.Mutex
+	levelRevert   *time.Timer
+	levelRevertAt time.Time
+)
+
+// LogLevelInfo 当前的日志级别
+type LogLevelInfo struct {
+	Level    string `json:"level"`
+	Config   string `json:"config"`             // 配置文件中的级别
+	RevertAt string `json:"revertAt,omitempty"` // 临时修改时, 自动恢复为配置级别的时间
+}
+
+// GetLogLevel 返回当前的日志级别
+func GetLogLevel() LogLevelInfo {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	info := LogLevelInfo{
+		Level:  logLevel.String(),
+		Config: configLogLevel().String(),
+	}
+	if levelRevert != nil {
+		info.RevertAt = levelRevertAt.Format(time.DateTime)
+	}
+
+	return info
+}
+
+// SetLogLevel 修改日志级别, revertAfter 大于 0 时到期后自动恢复为配置文件中的级别, 适用于临时开启 debug 日志排查问题
+func SetLogLevel(level string, revertAfter time.Duration) error {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("无效的日志级别 %q, 可选值: debug、info、warn、error、fatal", level)
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	stopLevelRevert()
+	logLevel.SetLevel(lvl)
+	if revertAfter > 0 {
+		// 回调中先检查定时器是否仍是当前的, 避免等锁期间被新的修改替换后, 误取消新的临时级别
+		var timer *time.Timer
+		timer = time.AfterFunc(revertAfter, func() {
+			revertLogLevel(timer)
+		})
+		levelRevertAt = time.Now().Add(revertAfter)
+		levelRevert = timer
+	}
+
+	return nil
+}
+
+// resetLogLevel 恢复为配置文件中的日志级别, 并取消自动恢复
+func resetLogLevel() {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	stopLevelRevert()
+	logLevel.SetLevel(configLogLevel())
+}
+
+// revertLogLevel 自动恢复的定时器到期, timer 已经被新的修改替换或取消时不做处理
+func revertLogLevel(timer *time.Timer) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	if levelRevert != timer {
+		return
+	}
+	stopLevelRevert()
+	logLevel.SetLevel(configLogLevel())
+}
+
+// reloadLogLevel 配置热重载后更新为配置文件中的级别, 临时修改的级别保留到自动恢复, 恢复时使用新的配置级别
+func reloadLogLevel() {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	if levelRevert != nil {
+		return
+	}
+	logLevel.SetLevel(configLogLevel())
+}
+
+func stopLevelRevert() {
+	if levelRevert != nil {
+		levelRevert.Stop()
+		levelRevert = nil
+	}
+}
+
+// configLogLevel 配置文件中的日志级别, 未配置或配置错误时为 debug
+func configLogLevel() zapcore.Level {
+	lvl, err := zapcore.ParseLevel(GetConfig().Logger.Level)
+	if err != nil {
+		return zapcore.DebugLevel
+	}
+
+	return lvl
+}
+
+type setLogLevelReq struct {
+	Level    string `json:"level" form:"level"`
+	Duration string `json:"duration" form:"duration"` // 自动恢复的时间, 如 10m, 为空时不自动恢复
+}
+
+// LogLevelHandler 查看(GET)和修改(PUT/POST)日志级别的接口, 需要配合 gzmiddleware.AdminAuth 使用
+//
+//	adminGroup.Use(gzmiddleware.AdminAuth())
+//	adminGroup.Any("/log/level", base.LogLevelHandler())
+func LogLevelHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method == "GET" {
+			Success(ctx, GetLogLevel())
+			return
+		}
+
+		var req setLogLevelReq
+		if err := ctx.ShouldBind(&req); err != nil {
+			Fail(ctx, gzerror.ParameterIllegal)
+			return
+		}
+		var duration time.Duration
+		if req.Duration != "" {
+			var err error
+			if duration, err = time.ParseDuration(req.Duration); err != nil {
+				Fail(ctx, gzerror.ParameterIllegal, fmt.Sprintf("无效的时间 %q, 示例: 30s、10m、1h", req.Duration))
+				return
+			}
+		}
+		if err := SetLogLevel(req.Level, duration); err != nil {
+			Fail(ctx, gzerror.ParameterIllegal, err.Error())
+			return
+		}
+
+		Log.Warn("日志级别已修改", zap.String("level", req.Level), zap.String("duration", req.Duration), zap.String("ip", gzutil.GetClientRealIP(ctx)))
+		Success(ctx, GetLogLevel())
+	}
+}
@@ -0,0 +1,81 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzerror"
+	"github.com/w01fb0ss/gin-starter/pkg/gzhttp"
+)
+
+var (
+	logLevelUrl      string
+	logLevelDuration string
+)
+
+func init() {
+	logLevelCmd.Flags().StringVar(&logLevelUrl, "url", "", "Log level endpoint, default http://127.0.0.1:<App.Addr port><App.RouterPrefix>/admin/log/level")
+	logLevelCmd.Flags().StringVar(&logLevelDuration, "duration", "", "Revert to the configured level after the duration, e.g. 10m")
+	gzconsole.RootCmd.AddCommand(logLevelCmd)
+}
+
+var logLevelCmd = &cobra.Command{
+	Use:         "loglevel [level]",
+	Short:       "Show or change the log level of the running service",
+	Long:        `查看或修改运行中服务的日志级别, 例如: loglevel debug --duration 10m, 需要在路由中注册 base.LogLevelHandler`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{annotationConfigOnly: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url := logLevelUrl
+		if url == "" {
+			url = defaultLogLevelUrl()
+		}
+
+		req := gzhttp.RequestConfig{
+			Method:  gzhttp.MethodGet,
+			Url:     url,
+			Headers: map[string]string{"X-Admin-Token": viper.GetString("Admin.Token")},
+			Timeout: 5 * time.Second,
+		}
+		if len(args) > 0 {
+			req.Method = gzhttp.MethodPut
+			req.Params = map[string]interface{}{"level": args[0], "duration": logLevelDuration}
+		}
+
+		var resp struct {
+			Response
+			Data LogLevelInfo `json:"data"`
+		}
+		status, err := gzhttp.DoJSON(req, &resp)
+		if err != nil {
+			return fmt.Errorf("请求 %s 失败: %s", url, err)
+		}
+		if resp.Code != gzerror.OK {
+			return fmt.Errorf("请求 %s 失败: [%d] %d %s", url, status, resp.Code, resp.Msg)
+		}
+
+		out, _ := json.MarshalIndent(resp.Data, "", "  ")
+		fmt.Println(string(out))
+
+		return nil
+	},
+}
+
+// defaultLogLevelUrl 根据 App.Addr 和 App.RouterPrefix 拼接本机的接口地址
+func defaultLogLevelUrl() string {
+	host, port, err := net.SplitHostPort(viper.GetString("App.Addr"))
+	if err != nil {
+		host, port = "", "80"
+	}
+	if host == "" || net.ParseIP(host) != nil && net.ParseIP(host).IsUnspecified() {
+		host = "127.0.0.1"
+	}
+
+	return "http://" + net.JoinHostPort(host, port) + strings.TrimSuffix(viper.GetString("App.RouterPrefix"), "/") + "/admin/log/level"
+}
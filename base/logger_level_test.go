@@ -0,0 +1,80 @@
+package base
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func useLogLevel(t *testing.T, level string) {
+	conf := &BaseConfig{}
+	conf.Logger.Level = level
+	useConfig(t, conf)
+}
+
+func TestReloadKeepsTemporaryLogLevel(t *testing.T) {
+	useLogLevel(t, "warn")
+	resetLogLevel()
+	defer resetLogLevel()
+	if err := SetLogLevel("debug", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// 热重载只更新配置级别, 临时修改的级别和自动恢复时间不变
+	useLogLevel(t, "info")
+	reloadLogLevel()
+	info := GetLogLevel()
+	if info.Level != "debug" || info.Config != "info" || info.RevertAt == "" {
+		t.Fatalf("热重载后为 %+v", info)
+	}
+
+	// 自动恢复时使用新的配置级别
+	resetLogLevel()
+	if logLevel.Level() != zapcore.InfoLevel {
+		t.Fatalf("恢复后为 %s", logLevel.Level())
+	}
+}
+
+func TestReloadUpdatesLogLevel(t *testing.T) {
+	useLogLevel(t, "warn")
+	defer resetLogLevel()
+
+	if err := SetLogLevel("error", 0); err != nil {
+		t.Fatal(err)
+	}
+	useLogLevel(t, "info")
+	reloadLogLevel()
+	if info := GetLogLevel(); info.Level != "info" || info.RevertAt != "" {
+		t.Fatalf("热重载后为 %+v", info)
+	}
+}
+
+func TestStaleRevertKeepsNewLogLevel(t *testing.T) {
+	useLogLevel(t, "warn")
+	defer resetLogLevel()
+
+	if err := SetLogLevel("debug", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	levelMu.Lock()
+	stale := levelRevert
+	levelMu.Unlock()
+
+	// 旧的定时器在等锁期间被新的修改替换, 到期后不应取消新的临时级别
+	if err := SetLogLevel("error", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	revertLogLevel(stale)
+	if info := GetLogLevel(); info.Level != "error" || info.RevertAt == "" {
+		t.Fatalf("旧的定时器到期后为 %+v", info)
+	}
+
+	levelMu.Lock()
+	current := levelRevert
+	levelMu.Unlock()
+	revertLogLevel(current)
+	if info := GetLogLevel(); info.Level != "warn" || info.RevertAt != "" {
+		t.Fatalf("自动恢复后为 %+v", info)
+	}
+}
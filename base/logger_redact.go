@@ -0,0 +1,100 @@
+package base
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzredact"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var redactor atomic.Pointer[gzredact.Redactor]
+
+// Redactor 返回当前的脱敏器, 关闭脱敏时返回 nil, gzredact.Redactor 的方法都可以在 nil 上调用
+func Redactor() *gzredact.Redactor {
+	return redactor.Load()
+}
+
+// loadRedactor 根据 Redact 配置创建脱敏器, 自定义规则有误时只使用内置规则
+func loadRedactor() {
+	conf := GetConfig().Redact
+	if conf.Disable {
+		redactor.Store(nil)
+		return
+	}
+
+	r, err := gzredact.New(append(append([]gzredact.Rule{}, gzredact.DefaultRules...), conf.Rules...))
+	if err != nil {
+		gzconsole.Echo.Warnf("⚠️  警告: 日志脱敏规则有误, 只使用内置规则: %s\n", err)
+		r, _ = gzredact.New(gzredact.DefaultRules)
+	}
+	redactor.Store(r)
+}
+
+// redactCore 在写入前对日志消息和字段进行脱敏
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(Redactor(), fields))}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	return ce.AddCore(ent, c)
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if r := Redactor(); r != nil {
+		ent.Message = r.Text(ent.Message)
+		fields = redactFields(r, fields)
+	}
+
+	// 经过内层 core 的 Check, 保证按级别输出、采样和去重依然生效
+	if checked := c.Core.Check(ent, nil); checked != nil {
+		checked.Write(fields...)
+	}
+
+	return nil
+}
+
+func redactFields(r *gzredact.Redactor, fields []zapcore.Field) []zapcore.Field {
+	if r == nil || len(fields) == 0 {
+		return fields
+	}
+
+	result := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		result[i] = redactField(r, field)
+	}
+
+	return result
+}
+
+func redactField(r *gzredact.Redactor, field zapcore.Field) zapcore.Field {
+	switch field.Type {
+	case zapcore.StringType:
+		return zap.String(field.Key, r.String(field.Key, field.String))
+	case zapcore.ReflectType:
+		if out, err := r.Any(field.Key, field.Interface); err == nil {
+			return zap.Reflect(field.Key, out)
+		}
+	case zapcore.StringerType:
+		return zap.String(field.Key, r.String(field.Key, field.Interface.(fmt.Stringer).String()))
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type:
+		// 数字类型的手机号、证件号等
+		if r.MatchKey(field.Key) {
+			return zap.String(field.Key, r.String(field.Key, strconv.FormatInt(field.Integer, 10)))
+		}
+	}
+
+	return field
+}
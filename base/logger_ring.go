@@ -0,0 +1,135 @@
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zapcore"
+)
+
+// ringSink 在内存中保留最近的日志, 各个级别共享同一个缓冲区
+func ringSink(level zapcore.Level) (zapcore.WriteSyncer, error) {
+	ring, err := sharedLogSink("ring", func() (*logRingBuffer, error) {
+		return newLogRingBuffer(GetConfig().Logger.Ring.Size), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ringWriter{ring: ring, level: level}, nil
+}
+
+type ringEntry struct {
+	level zapcore.Level
+	line  []byte
+}
+
+type logRingBuffer struct {
+	mu      sync.RWMutex
+	entries []ringEntry
+	next    int
+	full    bool
+}
+
+func newLogRingBuffer(size int) *logRingBuffer {
+	if size <= 0 {
+		size = 1000
+	}
+
+	return &logRingBuffer{entries: make([]ringEntry, size)}
+}
+
+func (r *logRingBuffer) add(level zapcore.Level, p []byte) {
+	line := bytes.TrimRight(p, "\n")
+	entry := ringEntry{level: level, line: make([]byte, len(line))}
+	copy(entry.line, line)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// tail 按时间顺序返回最近 n 条不低于 minLevel 的日志
+func (r *logRingBuffer) tail(n int, minLevel zapcore.Level) [][]byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	size := r.next
+	if r.full {
+		size = len(r.entries)
+	}
+
+	var lines [][]byte
+	for i := 1; i <= size && len(lines) < n; i++ {
+		entry := r.entries[(r.next-i+len(r.entries))%len(r.entries)]
+		if entry.level >= minLevel {
+			lines = append(lines, entry.line)
+		}
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return lines
+}
+
+func (r *logRingBuffer) Close() error {
+	return nil
+}
+
+type ringWriter struct {
+	ring  *logRingBuffer
+	level zapcore.Level
+}
+
+func (w *ringWriter) Write(p []byte) (int, error) {
+	w.ring.add(w.level, p)
+
+	return len(p), nil
+}
+
+func (w *ringWriter) Sync() error {
+	return nil
+}
+
+// LogTail 返回内存中最近 n 条不低于 level 的日志, 需要在 Log.Mode 中开启 ring
+func LogTail(n int, level string) []json.RawMessage {
+	sinkMu.RLock()
+	ring, ok := sharedSinks["ring"].(*logRingBuffer)
+	sinkMu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	minLevel, err := zapcore.ParseLevel(level)
+	if err != nil {
+		minLevel = zapcore.DebugLevel
+	}
+	lines := ring.tail(n, minLevel)
+	result := make([]json.RawMessage, len(lines))
+	for i, line := range lines {
+		result[i] = line
+	}
+
+	return result
+}
+
+// LogTailHandler 查看内存中最近日志的接口, 参数 n 为条数(默认 100), level 为最低级别, 需要配合 gzmiddleware.AdminAuth 使用
+func LogTailHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		n, err := strconv.Atoi(ctx.DefaultQuery("n", "100"))
+		if err != nil || n <= 0 {
+			n = 100
+		}
+
+		Success(ctx, LogTail(n, ctx.Query("level")))
+	}
+}
@@ -0,0 +1,84 @@
+package base
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func joinLines(lines [][]byte) string {
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = string(line)
+	}
+
+	return strings.Join(result, ",")
+}
+
+func TestLogRingBufferTail(t *testing.T) {
+	ring := newLogRingBuffer(3)
+	if got := joinLines(ring.tail(10, zapcore.DebugLevel)); got != "" {
+		t.Fatalf("空缓冲区返回 %q", got)
+	}
+
+	ring.add(zapcore.InfoLevel, []byte("1\n"))
+	ring.add(zapcore.ErrorLevel, []byte("2\n"))
+	if got := joinLines(ring.tail(10, zapcore.DebugLevel)); got != "1,2" {
+		t.Fatalf("未写满时返回 %q", got)
+	}
+
+	ring.add(zapcore.WarnLevel, []byte("3\n"))
+	ring.add(zapcore.InfoLevel, []byte("4\n"))
+	ring.add(zapcore.ErrorLevel, []byte("5\n"))
+	tests := []struct {
+		n        int
+		minLevel zapcore.Level
+		want     string
+	}{
+		{10, zapcore.DebugLevel, "3,4,5"},
+		{2, zapcore.DebugLevel, "4,5"},
+		{10, zapcore.WarnLevel, "3,5"},
+		{1, zapcore.WarnLevel, "5"},
+		{10, zapcore.FatalLevel, ""},
+	}
+	for _, tt := range tests {
+		if got := joinLines(ring.tail(tt.n, tt.minLevel)); got != tt.want {
+			t.Errorf("tail(%d, %s) = %q, 期望 %q", tt.n, tt.minLevel, got, tt.want)
+		}
+	}
+}
+
+func TestLogRingBufferCopiesLine(t *testing.T) {
+	ring := newLogRingBuffer(2)
+	// zap 会复用写入的内存
+	p := []byte("a\n")
+	ring.add(zapcore.InfoLevel, p)
+	p[0] = 'b'
+
+	if got := joinLines(ring.tail(1, zapcore.DebugLevel)); got != "a" {
+		t.Fatalf("返回 %q, 期望 a", got)
+	}
+}
+
+func TestLogTail(t *testing.T) {
+	ring := newLogRingBuffer(10)
+	ring.add(zapcore.InfoLevel, []byte(`{"msg":"a"}`))
+	ring.add(zapcore.ErrorLevel, []byte(`{"msg":"b"}`))
+	sinkMu.Lock()
+	sharedSinks["ring"] = ring
+	sinkMu.Unlock()
+	defer func() {
+		sinkMu.Lock()
+		delete(sharedSinks, "ring")
+		sinkMu.Unlock()
+	}()
+
+	if got := LogTail(10, "error"); len(got) != 1 || string(got[0]) != `{"msg":"b"}` {
+		t.Fatalf("LogTail(10, error) = %s", got)
+	}
+	// 无效的级别视为 debug
+	if got := LogTail(10, "unknown"); len(got) != 2 {
+		t.Fatalf("LogTail(10, unknown) 返回 %d 条", len(got))
+	}
+}
@@ -0,0 +1,130 @@
+package base
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// wrapLevelCore 按照 Log.Sampling、Log.Dedup 中对应级别的配置, 为 core 增加采样和去重
+func wrapLevelCore(core zapcore.Core, level zapcore.Level) zapcore.Core {
+	logConf := GetConfig().Logger
+	if conf, ok := logConf.Sampling[level.String()]; ok && conf.Initial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, conf.Initial, conf.Thereafter)
+	}
+
+	if window := logConf.Dedup[level.String()]; window > 0 {
+		core = newDedupCore(core, time.Duration(window)*time.Second)
+	}
+
+	return core
+}
+
+// dedupCore 在时间窗口内相同的消息只输出第一条, 窗口结束时再输出一条带重复次数的日志
+// 消息相同即视为重复, 不比较字段, 这样同一个错误在不同请求中(trace_id 不同)也会被合并
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+}
+
+type dedupState struct {
+	mu      sync.Mutex
+	window  time.Duration
+	pending map[string]*dedupEntry
+}
+
+type dedupEntry struct {
+	core     zapcore.Core
+	entry    zapcore.Entry
+	fields   []zapcore.Field
+	repeated int
+	timer    *time.Timer
+}
+
+func newDedupCore(core zapcore.Core, window time.Duration) zapcore.Core {
+	return &dedupCore{
+		Core: core,
+		state: &dedupState{
+			window:  window,
+			pending: make(map[string]*dedupEntry),
+		},
+	}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	return ce.AddCore(ent, c)
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := ent.Message
+	c.state.mu.Lock()
+	if pending, ok := c.state.pending[key]; ok {
+		pending.repeated++
+		c.state.mu.Unlock()
+		return nil
+	}
+	c.state.pending[key] = &dedupEntry{
+		core:   c.Core,
+		entry:  ent,
+		fields: fields,
+		timer:  time.AfterFunc(c.state.window, func() { c.state.flush(key) }),
+	}
+	c.state.mu.Unlock()
+
+	// 经过内层 core 的 Check, 保证采样依然生效
+	if checked := c.Core.Check(ent, nil); checked != nil {
+		checked.Write(fields...)
+	}
+
+	return nil
+}
+
+func (c *dedupCore) Sync() error {
+	c.state.flushAll()
+
+	return c.Core.Sync()
+}
+
+// flush 结束 key 的时间窗口, 窗口内有重复时输出一条汇总日志
+func (s *dedupState) flush(key string) {
+	s.mu.Lock()
+	pending, ok := s.pending[key]
+	if ok {
+		delete(s.pending, key)
+	}
+	s.mu.Unlock()
+
+	if ok && pending.repeated > 0 {
+		ent := pending.entry
+		ent.Time = time.Now()
+		fields := append(pending.fields[:len(pending.fields):len(pending.fields)],
+			zap.Int("repeated", pending.repeated),
+			zap.Time("first_time", pending.entry.Time),
+		)
+		_ = pending.core.Write(ent, fields)
+	}
+}
+
+func (s *dedupState) flushAll() {
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.pending))
+	for key, pending := range s.pending {
+		pending.timer.Stop()
+		keys = append(keys, key)
+	}
+	s.mu.Unlock()
+
+	for _, key := range keys {
+		s.flush(key)
+	}
+}
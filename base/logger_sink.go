@@ -0,0 +1,237 @@
+package base
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LogSink 创建某个级别的日志输出, 每个级别都会调用一次
+// 网络类的 sink 应该在各个级别之间共享连接, 并且在日志重建(轮转、热重载)时复用, 参考 sharedLogSink
+// 非共享的输出实现了 io.Closer 时, 会在日志重建后关闭
+type LogSink func(level zapcore.Level) (zapcore.WriteSyncer, error)
+
+var (
+	sinkMu   sync.RWMutex
+	logSinks = map[string]LogSink{
+		"console": consoleSink,
+		"file":    fileSink,
+		"close":   closeSink,
+		"syslog":  syslogSink,
+		"tcp":     tcpSink,
+		"ring":    ringSink,
+	}
+
+	// sharedSinks 在日志重建之间复用的 sink, 重建后不再使用的和进程退出时关闭
+	sharedSinks = make(map[string]io.Closer)
+	// usedSharedSinks 正在进行的日志重建中用到的共享 sink
+	usedSharedSinks map[string]bool
+	// ownedSinks 当前日志独占的 sink, 如 level 布局下每个级别的文件
+	ownedSinks []io.Closer
+)
+
+// RegisterLogSink 注册日志输出, 注册后可以在 Log.Mode 中使用, name 相同时覆盖
+func RegisterLogSink(name string, sink LogSink) {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+
+	logSinks[name] = sink
+}
+
+// hasLogSink 是否存在名为 name 的日志输出
+func hasLogSink(name string) bool {
+	sinkMu.RLock()
+	defer sinkMu.RUnlock()
+
+	_, ok := logSinks[name]
+
+	return ok
+}
+
+// logModes 解析 Log.Mode, 支持列表和逗号分隔的字符串, both 等同于 console + file
+func logModes(modes []string) []string {
+	var result []string
+	for _, mode := range modes {
+		for _, name := range strings.Split(mode, ",") {
+			name = strings.TrimSpace(name)
+			switch name {
+			case "":
+			case "both":
+				result = append(result, "console", "file")
+			default:
+				result = append(result, name)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return []string{"console", "file"}
+	}
+
+	return result
+}
+
+// A WriteSyncer is an io.Writer that can also flush any buffered data. Note
+// that *os.File (and thus, os.Stderr and os.Stdout) implement WriteSyncer.
+// 按照 Log.Mode 组合所有 sink 的输出, 创建失败的 sink 会被跳过
+func getLogWriter(level zapcore.Level, owned *[]io.Closer) zapcore.WriteSyncer {
+	var writers []zapcore.WriteSyncer
+	for _, name := range logModes(GetConfig().Logger.Mode) {
+		sinkMu.RLock()
+		sink, ok := logSinks[name]
+		sinkMu.RUnlock()
+		if !ok {
+			gzconsole.Echo.Warnf("⚠️  警告: 未知的日志输出 %s, 已忽略\n", name)
+			continue
+		}
+
+		writer, err := sink(level)
+		if err != nil {
+			gzconsole.Echo.Warnf("⚠️  警告: 日志输出 %s 初始化失败, 已忽略: %s\n", name, err)
+			continue
+		}
+		writers = append(writers, writer)
+		if closer, ok := writer.(io.Closer); ok && !isStdStream(closer) && !isSharedSink(closer) {
+			*owned = append(*owned, closer)
+		}
+	}
+
+	return zapcore.NewMultiWriteSyncer(writers...)
+}
+
+// sharedLogSink 返回 key 对应的共享实例, 不存在时调用 create 创建
+func sharedLogSink[T io.Closer](key string, create func() (T, error)) (T, error) {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+
+	if sink, ok := sharedSinks[key].(T); ok {
+		markSharedSink(key)
+		return sink, nil
+	}
+	sink, err := create()
+	if err != nil {
+		return sink, err
+	}
+	sharedSinks[key] = sink
+	markSharedSink(key)
+
+	return sink, nil
+}
+
+func markSharedSink(key string) {
+	if usedSharedSinks != nil {
+		usedSharedSinks[key] = true
+	}
+}
+
+// isStdStream zapcore.AddSync(os.Stdout) 返回的就是 *os.File, 不能当作独占的 sink 关闭
+func isStdStream(closer io.Closer) bool {
+	return closer == os.Stdout || closer == os.Stderr
+}
+
+func isSharedSink(closer io.Closer) bool {
+	sinkMu.RLock()
+	defer sinkMu.RUnlock()
+
+	for _, sink := range sharedSinks {
+		if sink == closer {
+			return true
+		}
+	}
+
+	return false
+}
+
+// beginLogSinks 开始记录日志重建用到的共享 sink
+func beginLogSinks() {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+
+	usedSharedSinks = make(map[string]bool)
+}
+
+// releaseLogSinks 在新的日志生效后调用, 关闭旧日志独占的 sink 和本次重建没有用到的共享 sink (如热重载修改了 syslog 地址)
+func releaseLogSinks(owned []io.Closer) {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+
+	for _, sink := range ownedSinks {
+		_ = sink.Close()
+	}
+	ownedSinks = owned
+	for key, sink := range sharedSinks {
+		if !usedSharedSinks[key] {
+			_ = sink.Close()
+			delete(sharedSinks, key)
+		}
+	}
+	usedSharedSinks = nil
+}
+
+// closeLogSinks 关闭所有 sink, 在进程退出前调用
+func closeLogSinks() {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+
+	for _, sink := range ownedSinks {
+		_ = sink.Close()
+	}
+	ownedSinks = nil
+	for key, sink := range sharedSinks {
+		_ = sink.Close()
+		delete(sharedSinks, key)
+	}
+}
+
+func consoleSink(zapcore.Level) (zapcore.WriteSyncer, error) {
+	return zapcore.AddSync(os.Stdout), nil
+}
+
+func closeSink(zapcore.Level) (zapcore.WriteSyncer, error) {
+	return zapcore.AddSync(io.Discard), nil
+}
+
+// fileSink 按照日期和级别输出到文件, 如 logs/2006-01-02/info.log, Log.Layout 为 single 时每天一个文件
+func fileSink(level zapcore.Level) (zapcore.WriteSyncer, error) {
+	conf := GetConfig().Logger
+	if conf.Layout == logLayoutSingle {
+		return singleFileSink(level)
+	}
+
+	path := conf.Path
+	maxSize := conf.MaxSize
+	maxBackups := conf.MaxBackups
+	maxAge := conf.MaxAge
+	compress := conf.Compress
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	fileName := fmt.Sprintf("%s%s/%s.log", path, time.Now().Format("2006-01-02"), level)
+	if conf.Recover {
+		return newCustomWrite(fileName, maxSize, maxBackups, maxAge, compress), nil
+	}
+
+	return fileWriter{&lumberjack.Logger{
+		Filename:   fileName,
+		MaxSize:    maxSize,    // 单文件最大容量, 单位是MB
+		MaxBackups: maxBackups, // 最大保留过期文件个数
+		MaxAge:     maxAge,     // 保留过期文件的最大时间间隔, 单位是天
+		Compress:   compress,   // 是否需要压缩滚动日志, 使用的gzip压缩
+		LocalTime:  true,       // 是否使用计算机的本地时间, 默认UTC
+	}}, nil
+}
+
+// fileWriter 保留 lumberjack 的 Close, 日志重建后关闭旧的文件
+type fileWriter struct {
+	*lumberjack.Logger
+}
+
+func (w fileWriter) Sync() error {
+	return nil
+}
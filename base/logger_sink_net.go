@@ -0,0 +1,343 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogSeverity zap 日志级别对应的 syslog severity
+var syslogSeverity = map[zapcore.Level]int{
+	zapcore.DebugLevel:  7,
+	zapcore.InfoLevel:   6,
+	zapcore.WarnLevel:   4,
+	zapcore.ErrorLevel:  3,
+	zapcore.DPanicLevel: 2,
+	zapcore.PanicLevel:  2,
+	zapcore.FatalLevel:  2,
+}
+
+// syslogSink 以 RFC5424 格式发送到 syslog, 各个级别共享同一个连接
+// 与 tcpSink 相同, 日志由后台协程发送和重连, syslog 不可用时不会阻塞业务, 队列满后丢弃新的日志
+func syslogSink(level zapcore.Level) (zapcore.WriteSyncer, error) {
+	conf := GetConfig().Logger.Syslog
+	network := conf.Network
+	if network == "" {
+		network = "udp"
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("不支持的协议 %s, 可选值: udp、tcp", network)
+	}
+	addr := conf.Addr
+	if addr == "" {
+		return nil, fmt.Errorf("未配置 Log.Syslog.Addr")
+	}
+	tag := gzutil.Ternary(conf.Tag != "", conf.Tag, GetConfig().App.Name)
+	facility := conf.Facility
+	if facility == 0 {
+		facility = 16
+	}
+	bufferSize := conf.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 10000
+	}
+
+	key := fmt.Sprintf("syslog|%s|%s|%s|%d|%d", network, addr, tag, facility, bufferSize)
+	client, err := sharedLogSink(key, func() (*syslogClient, error) {
+		return newSyslogClient(network, addr, tag, facility, bufferSize)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &syslogWriter{client: client, severity: syslogSeverity[level]}, nil
+}
+
+// syslogClient 格式化 syslog 消息, 由 netShipper 在后台发送
+type syslogClient struct {
+	tcp      bool
+	tag      string
+	facility int
+	hostname string
+	shipper  *netShipper
+}
+
+func newSyslogClient(network, addr, tag string, facility, bufferSize int) (*syslogClient, error) {
+	// 启动时先连接一次, 尽早发现地址配置错误, 之后的重连由后台协程完成
+	conn, err := net.DialTimeout(network, addr, 3*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("连接 syslog %s://%s 失败: %s", network, addr, err)
+	}
+	hostname, _ := os.Hostname()
+
+	return &syslogClient{
+		tcp:      network == "tcp",
+		tag:      gzutil.Ternary(tag != "", tag, "-"),
+		facility: facility,
+		hostname: gzutil.Ternary(hostname != "", hostname, "-"),
+		shipper:  newNetShipper(network, addr, bufferSize, conn),
+	}, nil
+}
+
+// format 按 RFC5424 格式化一条日志, TCP 使用 RFC6587 octet counting 分帧
+func (c *syslogClient) format(severity int, msg []byte) []byte {
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d - - ", c.facility*8+severity,
+		time.Now().Format(time.RFC3339Nano), c.hostname, c.tag, os.Getpid())
+	buf.Write(bytes.TrimRight(msg, "\n"))
+	if !c.tcp {
+		return buf.Bytes()
+	}
+
+	return append([]byte(strconv.Itoa(buf.Len())+" "), buf.Bytes()...)
+}
+
+func (c *syslogClient) Close() error {
+	return c.shipper.Close()
+}
+
+type syslogWriter struct {
+	client   *syslogClient
+	severity int
+}
+
+// Write 放入发送队列后立即返回, syslog 不可用时不会阻塞业务
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	if err := w.client.shipper.enqueue(w.client.format(w.severity, p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (w *syslogWriter) Sync() error {
+	return w.client.shipper.Sync()
+}
+
+// tcpSink 以换行分隔的 JSON 发送到 TCP 日志收集器, 各个级别共享同一个连接
+// 日志先写入缓冲队列, 由后台协程发送, 收集器不可用时不会阻塞业务, 队列满后丢弃新的日志
+func tcpSink(zapcore.Level) (zapcore.WriteSyncer, error) {
+	conf := GetConfig().Logger.Tcp
+	addr := conf.Addr
+	if addr == "" {
+		return nil, fmt.Errorf("未配置 Log.Tcp.Addr")
+	}
+	bufferSize := conf.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 10000
+	}
+
+	return sharedLogSink(fmt.Sprintf("tcp|%s|%d", addr, bufferSize), func() (*netShipper, error) {
+		return newNetShipper("tcp", addr, bufferSize, nil), nil
+	})
+}
+
+// maxShipBatch 后台协程每次发送的最大日志条数
+const maxShipBatch = 256
+
+// netShipper 日志先写入缓冲队列, 由后台协程发送和重连, 远端不可用时不会阻塞业务, 队列满后丢弃新的日志
+type netShipper struct {
+	network string
+	addr    string
+	queue   chan []byte
+	pending atomic.Int64
+	dropped atomic.Int64
+	done    chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// newNetShipper conn 为已经建立的连接, 为空时由后台协程连接
+func newNetShipper(network, addr string, bufferSize int, conn net.Conn) *netShipper {
+	shipper := &netShipper{
+		network: network,
+		addr:    addr,
+		queue:   make(chan []byte, bufferSize),
+		done:    make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	gzutil.SafeGo(func() {
+		shipper.run(conn)
+	})
+
+	return shipper
+}
+
+// Write 复制一份日志放入队列, zap 会复用 p 的内存
+func (s *netShipper) Write(p []byte) (int, error) {
+	line := make([]byte, len(p))
+	copy(line, p)
+	if err := s.enqueue(line); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// enqueue 放入队列, 队列满时丢弃并计数, 重连成功后输出丢弃的条数
+func (s *netShipper) enqueue(line []byte) error {
+	select {
+	case <-s.done:
+		return fmt.Errorf("日志服务 %s://%s 已关闭", s.network, s.addr)
+	default:
+	}
+
+	s.pending.Add(1)
+	select {
+	case s.queue <- line:
+	default:
+		s.pending.Add(-1)
+		s.dropped.Add(1)
+	}
+
+	return nil
+}
+
+// Sync 等待队列中的日志发送完成, 最多等待 1 秒
+func (s *netShipper) Sync() error {
+	deadline := time.Now().Add(time.Second)
+	for s.pending.Load() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return nil
+}
+
+func (s *netShipper) Close() error {
+	s.once.Do(func() {
+		_ = s.Sync()
+		close(s.done)
+		<-s.closed
+	})
+
+	return nil
+}
+
+func (s *netShipper) run(conn net.Conn) {
+	defer close(s.closed)
+
+	var (
+		gone    chan struct{}
+		backoff = 500 * time.Millisecond
+		batch   [][]byte
+	)
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	if conn != nil {
+		gone = s.watch(conn)
+	}
+
+	for {
+		if len(batch) == 0 {
+			select {
+			case line := <-s.queue:
+				batch = append(batch[:0], line)
+			case <-s.done:
+				return
+			}
+		}
+		batch = s.fill(batch)
+
+		// 远端主动断开时, 重连后再发送, 避免写入已关闭的连接导致日志丢失
+		if conn != nil {
+			select {
+			case <-gone:
+				_ = conn.Close()
+				conn = nil
+			default:
+			}
+		}
+		if conn == nil {
+			var err error
+			if conn, err = net.DialTimeout(s.network, s.addr, 3*time.Second); err != nil {
+				conn = nil
+				select {
+				case <-time.After(backoff):
+				case <-s.done:
+					return
+				}
+				backoff = min(backoff*2, 30*time.Second)
+				continue
+			}
+			gone = s.watch(conn)
+			backoff = 500 * time.Millisecond
+			if dropped := s.dropped.Swap(0); dropped > 0 {
+				gzconsole.Echo.Warnf("⚠️  警告: 日志服务 %s://%s 不可用期间丢弃了 %d 条日志\n", s.network, s.addr, dropped)
+			}
+		}
+
+		sent, err := s.send(conn, batch)
+		s.pending.Add(-int64(sent))
+		batch = batch[sent:]
+		if err != nil {
+			_ = conn.Close()
+			conn = nil
+		}
+	}
+}
+
+// send 发送一批日志, 返回已发送的条数
+// TCP 整批写入, 失败时重连后整批重发, 保证顺序, 可能会有重复但不会丢失; UDP 每条日志一个数据包
+func (s *netShipper) send(conn net.Conn, batch [][]byte) (int, error) {
+	_ = conn.SetWriteDeadline(time.Now().Add(3 * time.Second))
+	if s.network == "udp" {
+		for i, line := range batch {
+			if _, err := conn.Write(line); err != nil {
+				return i, err
+			}
+		}
+
+		return len(batch), nil
+	}
+
+	buffers := make(net.Buffers, len(batch))
+	copy(buffers, batch)
+	if _, err := buffers.WriteTo(conn); err != nil {
+		return 0, err
+	}
+
+	return len(batch), nil
+}
+
+// fill 把队列中已有的日志合并到当前批次, 每批最多 maxShipBatch 条
+func (s *netShipper) fill(batch [][]byte) [][]byte {
+	for len(batch) < maxShipBatch {
+		select {
+		case line := <-s.queue:
+			batch = append(batch, line)
+		default:
+			return batch
+		}
+	}
+
+	return batch
+}
+
+// watch 远端不会发送数据, TCP 连接读取返回时说明连接已经断开; UDP 没有连接状态, 返回的 channel 不会关闭
+func (s *netShipper) watch(conn net.Conn) chan struct{} {
+	gone := make(chan struct{})
+	if s.network == "udp" {
+		return gone
+	}
+	gzutil.SafeGo(func() {
+		defer close(gone)
+		_, _ = io.Copy(io.Discard, conn)
+	})
+
+	return gone
+}
@@ -0,0 +1,227 @@
+package base
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// readLines 读取 conn 上换行分隔的 n 行
+func readLines(t *testing.T, conn net.Conn, n int) []string {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	lines := make([]string, 0, n)
+	for len(lines) < n {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("读取第 %d 行失败: %s", len(lines), err)
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+
+	return lines
+}
+
+func accept(t *testing.T, ln net.Listener) net.Conn {
+	t.Helper()
+
+	_ = ln.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("等待连接失败: %s", err)
+	}
+
+	return conn
+}
+
+func writeSeq(shipper *netShipper, from, to int) {
+	for i := from; i < to; i++ {
+		_, _ = shipper.Write([]byte(fmt.Sprintf("%d\n", i)))
+	}
+}
+
+func TestNetShipperReconnectKeepsOrder(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	shipper := newNetShipper("tcp", addr, 100, nil)
+	defer shipper.Close()
+
+	writeSeq(shipper, 0, 10)
+	conn := accept(t, ln)
+	if got := strings.Join(readLines(t, conn, 10), ","); got != "0,1,2,3,4,5,6,7,8,9" {
+		t.Fatalf("第一个连接收到 %s", got)
+	}
+
+	// 收集器重启: 断开连接并停止监听, 期间的日志留在队列中
+	_ = conn.Close()
+	_ = ln.Close()
+	time.Sleep(100 * time.Millisecond)
+	writeSeq(shipper, 10, 20)
+	time.Sleep(200 * time.Millisecond)
+
+	if ln, err = net.Listen("tcp", addr); err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	conn = accept(t, ln)
+	defer conn.Close()
+	if got := strings.Join(readLines(t, conn, 10), ","); got != "10,11,12,13,14,15,16,17,18,19" {
+		t.Fatalf("重连后收到 %s", got)
+	}
+	if err = shipper.Sync(); err != nil || shipper.pending.Load() != 0 {
+		t.Fatalf("发送完成后 pending = %d", shipper.pending.Load())
+	}
+}
+
+func TestNetShipperCountsDropped(t *testing.T) {
+	// 获取一个没有监听的端口
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	shipper := newNetShipper("tcp", addr, 10, nil)
+	defer shipper.Close()
+	writeSeq(shipper, 0, 1000)
+
+	pending, dropped := shipper.pending.Load(), shipper.dropped.Load()
+	if pending+dropped != 1000 {
+		t.Fatalf("pending(%d) + dropped(%d) != 1000", pending, dropped)
+	}
+	if dropped < 1000-10-maxShipBatch {
+		t.Fatalf("dropped = %d, 队列已满时应丢弃", dropped)
+	}
+}
+
+var syslogPattern = regexp.MustCompile(`^<131>1 (\S+) \S+ demo \d+ - - (.*)$`)
+
+func checkSyslogLine(t *testing.T, line, msg string) {
+	t.Helper()
+
+	match := syslogPattern.FindStringSubmatch(line)
+	if match == nil {
+		t.Fatalf("不是 RFC5424 格式: %q", line)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, match[1]); err != nil {
+		t.Fatalf("时间格式错误: %s", err)
+	}
+	if match[2] != msg {
+		t.Fatalf("消息为 %q, 期望 %q", match[2], msg)
+	}
+}
+
+func TestSyslogUdpFraming(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	client, err := newSyslogClient("udp", pc.LocalAddr().String(), "demo", 16, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	writer := &syslogWriter{client: client, severity: syslogSeverity[zapcore.ErrorLevel]}
+	for _, msg := range []string{`{"msg":"a"}`, `{"msg":"b"}`} {
+		if _, err = writer.Write([]byte(msg + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 每条日志一个数据包
+	buf := make([]byte, 2048)
+	for _, msg := range []string{`{"msg":"a"}`, `{"msg":"b"}`} {
+		_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSyslogLine(t, string(buf[:n]), msg)
+	}
+}
+
+func TestSyslogTcpFraming(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	client, err := newSyslogClient("tcp", ln.Addr().String(), "demo", 16, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	writer := &syslogWriter{client: client, severity: syslogSeverity[zapcore.ErrorLevel]}
+	messages := []string{`{"msg":"a"}`, `{"msg":"多行\nb"}`}
+	for _, msg := range messages {
+		if _, err = writer.Write([]byte(msg + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// RFC6587 octet counting: 长度 空格 消息
+	conn := accept(t, ln)
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	for _, msg := range messages {
+		length, err := reader.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+		if err != nil {
+			t.Fatalf("长度前缀错误: %q", length)
+		}
+		frame := make([]byte, n)
+		if _, err = io.ReadFull(reader, frame); err != nil {
+			t.Fatal(err)
+		}
+		checkSyslogLine(t, string(frame), msg)
+	}
+}
+
+func TestSyslogWriteDoesNotBlock(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := newSyslogClient("tcp", ln.Addr().String(), "demo", 16, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	conn := accept(t, ln)
+	_ = conn.Close()
+	_ = ln.Close()
+
+	// syslog 停止后写入只会进入队列或被丢弃, 不会等待重连
+	writer := &syslogWriter{client: client, severity: syslogSeverity[zapcore.InfoLevel]}
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		_, _ = writer.Write([]byte("x\n"))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("写入耗时 %s", elapsed)
+	}
+	if client.shipper.dropped.Load() == 0 {
+		t.Fatal("队列已满时应丢弃")
+	}
+}
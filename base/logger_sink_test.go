@@ -0,0 +1,95 @@
+package base
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type probeSink struct {
+	closed atomic.Bool
+}
+
+func (p *probeSink) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (p *probeSink) Sync() error {
+	return nil
+}
+
+func (p *probeSink) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+// useLogMode 使用指定的 Log.Mode 重建日志, 测试结束后关闭所有 sink 并恢复原来的日志
+func useLogMode(t *testing.T, mode string) {
+	conf := &BaseConfig{}
+	conf.Logger.Mode = []string{mode}
+	useConfig(t, conf)
+
+	prev := Log
+	t.Cleanup(func() {
+		closeLogSinks()
+		Log = prev
+	})
+}
+
+func TestRebuildClosesOldSinks(t *testing.T) {
+	var owned, shared []*probeSink
+	key := "a"
+	RegisterLogSink("probe", func(zapcore.Level) (zapcore.WriteSyncer, error) {
+		sink := &probeSink{}
+		owned = append(owned, sink)
+		return sink, nil
+	})
+	RegisterLogSink("probe-shared", func(zapcore.Level) (zapcore.WriteSyncer, error) {
+		return sharedLogSink("probe|"+key, func() (*probeSink, error) {
+			sink := &probeSink{}
+			shared = append(shared, sink)
+			return sink, nil
+		})
+	})
+	useLogMode(t, "probe,probe-shared")
+	newILog()
+	first := owned
+	newILog()
+	if len(owned) != 2*len(first) || len(shared) != 1 {
+		t.Fatalf("owned = %d, shared = %d", len(owned), len(shared))
+	}
+	for i, sink := range owned {
+		if sink.closed.Load() != (i < len(first)) {
+			t.Fatalf("第 %d 个独占 sink closed = %v", i, sink.closed.Load())
+		}
+	}
+	if shared[0].closed.Load() {
+		t.Fatal("仍在使用的共享 sink 不应关闭")
+	}
+
+	// 配置变化后旧的共享 sink 不再使用
+	key = "b"
+	newILog()
+	if len(shared) != 2 || !shared[0].closed.Load() || shared[1].closed.Load() {
+		t.Fatalf("共享 sink 未按预期关闭")
+	}
+}
+
+func TestRebuildKeepsStdout(t *testing.T) {
+	useLogMode(t, "console")
+	newILog()
+	newILog()
+	for _, sink := range ownedSinks {
+		if sink == os.Stdout {
+			t.Fatal("os.Stdout 不应作为独占的 sink")
+		}
+	}
+	closeLogSinks()
+
+	// 重建和退出后 stdout 仍然可以写入
+	if _, err := os.Stdout.Write(nil); err != nil {
+		t.Fatalf("stdout 已被关闭: %s", err)
+	}
+}
@@ -1,15 +1,22 @@
 package base
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzgrace"
 	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
 
+// DefaultShutdownTimeout 服务停止的默认最长等待时间, 可以通过 App.ShutdownTimeout 配置(单位: 秒)
+const DefaultShutdownTimeout = 30 * time.Second
+
 var serviceMgrCmd = &cobra.Command{
 	Use:    "Start",
 	Short:  "Web项目的服务启动",
@@ -21,30 +28,153 @@ var serviceMgrCmd = &cobra.Command{
 			return fmt.Errorf("请务必通过实现接口 `base.IService` 注册你要启动的服务")
 		}
 
-		var eg errgroup.Group
-		for _, service := range serviceList {
-			eg.Go(func() error {
-				if err := service.OnStart(); err != nil {
-					return fmt.Errorf("服务 %s: %v", gzutil.GetCallerName(service), err)
-				}
+		return runServices()
+	},
+}
+
+// runServices 并发启动所有服务, 收到退出信号或任何一个服务失败时, 通知所有服务停止, 并返回第一个错误
+func runServices() error {
+	stopSignals := handleSignals()
+	defer stopSignals()
+	defer serviceCancel()
+
+	eg, ctx := errgroup.WithContext(serviceCtx)
+	var readyWg sync.WaitGroup
+	for _, service := range serviceList {
+		name := gzutil.GetCallerName(service)
+		eg.Go(func() error {
+			if err := service.OnStart(); err != nil {
+				Log.Error("服务运行失败", zap.String("service", name), zap.Error(err))
+				return fmt.Errorf("服务 %s: %w", name, err)
+			}
+
+			return nil
+		})
 
-				return nil
+		if readiness, ok := service.(IServiceReady); ok {
+			RegisterHealthCheck("service:"+name, readiness.Ready)
+			readyWg.Add(1)
+			gzutil.SafeGo(func() {
+				defer readyWg.Done()
+				waitServiceReady(ctx, name, readiness)
 			})
 		}
+	}
 
-		// 等待所有任务完成
-		_ = eg.Wait()
-		os.Exit(124)
-		return nil
-	},
+	// 由平滑重启启动时, 所有实现了 IServiceReady 的服务就绪后通知父进程退出
+	// 没有这样的服务时无法判断新进程是否已经在提供服务, 不通知父进程, 父进程等待超时后继续提供服务
+	if hasReadyService() {
+		gzutil.SafeGo(func() {
+			readyWg.Wait()
+			if ctx.Err() != nil {
+				return
+			}
+			if err := gzgrace.Ready(); err != nil {
+				gzconsole.Echo.Warnf("⚠️  警告: 通知父进程失败: %s\n", err)
+			}
+		})
+	}
+
+	done := make(chan error, 1)
+	gzutil.SafeGo(func() {
+		done <- eg.Wait()
+	})
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	// 停止阶段: 先取消服务上下文, 所有服务共用一个截止时间
+	serviceCancel()
+	timeout := ShutdownTimeout()
+	stopCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	stopServices(stopCtx)
+
+	select {
+	case err := <-done:
+		return err
+	case <-stopCtx.Done():
+		return fmt.Errorf("服务未能在 %s 内全部停止", timeout)
+	}
 }
 
-func closeServiceMgr() {
-	_ = gzconsole.Echo.Sync()
-	_ = Log.Sync()
-	if rotationSchedulerProcess != nil {
-		rotationSchedulerProcess.Stop()
+// ShutdownTimeout 返回服务停止的最长等待时间, 即 App.ShutdownTimeout, 未配置时为 30 秒
+func ShutdownTimeout() time.Duration {
+	if seconds := GetConfig().App.ShutdownTimeout; seconds > 0 {
+		return time.Duration(seconds) * time.Second
 	}
+
+	return DefaultShutdownTimeout
+}
+
+// hasReadyService 是否有服务实现了 IServiceReady
+func hasReadyService() bool {
+	for _, service := range serviceList {
+		if _, ok := service.(IServiceReady); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// waitServiceReady 等待服务就绪并输出提示
+func waitServiceReady(ctx context.Context, name string, readiness IServiceReady) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if err := readiness.Ready(ctx); err == nil {
+			gzconsole.Echo.Infof("✅  提示: 服务 %s 已就绪\n", name)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// stopServices 调用所有实现了 IServiceStop 的服务的停止钩子
+func stopServices(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, service := range serviceList {
+		stopper, ok := service.(IServiceStop)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		gzutil.SafeGo(func() {
+			defer wg.Done()
+			name := gzutil.GetCallerName(service)
+			if err := stopper.Stop(ctx); err != nil {
+				gzconsole.Echo.Warnf("⚠️  警告: 服务 %s 停止失败: %s\n", name, err)
+				Log.Warn("服务停止失败", zap.String("service", name), zap.Error(err))
+			}
+		})
+	}
+	wg.Wait()
+}
+
+var closeOnce sync.Once
+
+// closeServiceMgr 按启动顺序的逆序停止各个模块, 最后再刷新日志
+func closeServiceMgr() {
+	closeOnce.Do(func() {
+		_ = gzconsole.Shutdown()
+		_ = gzconsole.Echo.Sync()
+		_ = Log.Sync()
+		closeLogSinks()
+		if rotationSchedulerProcess != nil {
+			rotationSchedulerProcess.Stop()
+		}
+	})
 }
 
 var serviceList []IService
@@ -57,6 +187,18 @@ type IService interface {
 	OnStart() error
 }
 
+// IServiceStop 服务可选实现的停止钩子, 当其他服务失败或进程退出时调用, ctx 携带停止的截止时间
+// 未实现该接口的服务可以通过 base.ServiceContext() 得知进程即将退出
+type IServiceStop interface {
+	Stop(ctx context.Context) error
+}
+
+// IServiceReady 服务可选实现的就绪检查, 返回 nil 表示服务已可以对外提供服务
+// 平滑重启时新进程只等待实现了该接口的服务就绪, 未实现的服务视为启动后立即就绪; 没有任何服务实现该接口时不支持平滑重启
+type IServiceReady interface {
+	Ready(ctx context.Context) error
+}
+
 type IServer struct {
 	IService
 }
@@ -0,0 +1,122 @@
+package base
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzgrace"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+	"go.uber.org/zap"
+)
+
+// defaultUpgradeTimeout 平滑重启时等待新进程就绪的默认最长时间, 可以通过 App.UpgradeTimeout 配置(单位: 秒)
+const defaultUpgradeTimeout = 60 * time.Second
+
+var (
+	// serviceCtx 所有服务共享的上下文, 收到退出信号或某个服务失败时被取消
+	serviceCtx, serviceCancel = context.WithCancel(context.Background())
+
+	reloadMu    sync.Mutex
+	reloadHooks []func()
+)
+
+// ServiceContext 返回服务管理器的上下文, 服务可以通过监听 Done() 得知进程即将退出
+func ServiceContext() context.Context {
+	return serviceCtx
+}
+
+// OnReload 注册收到 SIGHUP 信号时执行的重载函数
+func OnReload(fn func()) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadHooks = append(reloadHooks, fn)
+}
+
+func runReloadHooks() {
+	reloadMu.Lock()
+	hooks := append([]func(){}, reloadHooks...)
+	reloadMu.Unlock()
+
+	if len(hooks) == 0 {
+		gzconsole.Echo.Info("ℹ️ 提示: 收到 SIGHUP 信号, 但没有注册任何重载函数\n")
+		return
+	}
+	for _, hook := range hooks {
+		gzutil.RunSafe(hook)
+	}
+}
+
+// gracefulUpgrade 启动新进程接管所有监听, 新进程就绪后取消服务上下文, 当前进程处理完进行中的请求后退出
+func gracefulUpgrade() {
+	if serviceCtx.Err() != nil {
+		return
+	}
+	if !hasReadyService() {
+		gzconsole.Echo.Warn("⚠️  警告: 没有服务实现 base.IServiceReady, 无法确认新进程何时就绪, 不支持平滑重启\n")
+		return
+	}
+	gzconsole.Echo.Info("ℹ️ 提示: 收到平滑重启信号, 开始启动新进程\n")
+
+	timeout := defaultUpgradeTimeout
+	if seconds := GetConfig().App.UpgradeTimeout; seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(serviceCtx, timeout)
+	defer cancel()
+	if err := gzgrace.Upgrade(ctx); err != nil {
+		gzconsole.Echo.Warnf("⚠️  警告: 平滑重启失败, 继续使用当前进程: %s\n", err)
+		Log.Warn("平滑重启失败", zap.Error(err))
+		return
+	}
+
+	gzconsole.Echo.Info("✅  提示: 新进程已就绪, 开始停止当前进程\n")
+	serviceCancel()
+}
+
+// handleSignals 统一处理进程信号, SIGINT/SIGTERM 取消服务上下文, 再次收到则强制退出, SIGHUP 用于重载,
+// SIGUSR2 用于平滑重启(Windows 不支持)
+func handleSignals() (stop func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, append([]os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}, upgradeSignals...)...)
+	done := make(chan struct{})
+
+	gzutil.SafeGo(func() {
+		for {
+			select {
+			case s := <-sig:
+				if s == syscall.SIGHUP {
+					runReloadHooks()
+					continue
+				}
+				if isUpgradeSignal(s) {
+					gzutil.SafeGo(gracefulUpgrade)
+					continue
+				}
+
+				if serviceCtx.Err() != nil {
+					gzconsole.Echo.Warnf("⚠️  警告: 再次收到信号 %s, 强制退出\n", s)
+					os.Exit(1)
+				}
+				gzconsole.Echo.Infof("ℹ️ 提示: 收到信号 %s, 开始停止所有服务\n", s)
+				serviceCancel()
+			case <-done:
+				return
+			}
+		}
+	})
+
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
+
+func isUpgradeSignal(s os.Signal) bool {
+	return slices.Contains(upgradeSignals, s)
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package base
+
+import (
+	"os"
+	"syscall"
+)
+
+// upgradeSignals 触发平滑重启的信号
+var upgradeSignals = []os.Signal{syscall.SIGUSR2}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package base
+
+import "os"
+
+// upgradeSignals Windows 不支持平滑重启
+var upgradeSignals []os.Signal
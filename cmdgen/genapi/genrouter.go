@@ -287,6 +287,7 @@ const enterGoTemplate = `package router
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/w01fb0ss/gin-starter/base"
 	"github.com/w01fb0ss/gin-starter/pkg/gzmiddleware"
 	"github.com/spf13/viper"
 	"net/http"
@@ -302,14 +303,23 @@ func InitRouter() *gin.Engine {
 	r.Use(gzmiddleware.Begin()).Use(gzmiddleware.Cross()){{if .NeedRequestLog}}.Use(gzmiddleware.RequestLog()){{end}}
 	publicGroup := r.Group("{{ .RouterPrefix}}")
 	{
-		// 健康监测
-		publicGroup.GET("/health", func(c *gin.Context) {
-			c.JSON(200, "ok")
-		})
+		// 健康检查: livez 存活探针, readyz 就绪探针(检查数据库、Redis 等依赖), health 与 readyz 相同
+		publicGroup.GET("/livez", base.LivezHandler())
+		publicGroup.GET("/readyz", base.ReadyzHandler())
+		publicGroup.GET("/health", base.ReadyzHandler())
 
 		{{ if eq .GroupName "Public" }}{{.InitPublicFunctions}}(publicGroup){{ end }}
 	}
 
+	// 管理接口, 通过配置 admin.token、admin.allowIps 控制访问
+	adminGroup := r.Group("{{ .RouterPrefix}}/admin")
+	adminGroup.Use(gzmiddleware.AdminAuth())
+	{
+		adminGroup.GET("/log/level", base.LogLevelHandler())
+		adminGroup.PUT("/log/level", base.LogLevelHandler())
+		adminGroup.GET("/log/tail", base.LogTailHandler())
+	}
+
 	{{ if eq .GroupName "Auth" }}
 	privateAuthGroup := r.Group("{{ .RouterPrefix}}")
 	privateAuthGroup.Use(gzmiddleware.Jwt()).Use(gzmiddleware.Casbin())
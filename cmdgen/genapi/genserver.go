@@ -15,6 +15,8 @@ const serverContentTemplate = `
 package {{.PackageName}}
 
 import (
+	"context"
+
 	"github.com/w01fb0ss/gin-starter/base"
 	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
 	"github.com/w01fb0ss/gin-starter/modules/httpmodule"
@@ -35,13 +37,23 @@ func (self *{{ .ServerName}}) OnStart() (err error) {
 	// 添加回调函数
 	self.httpModule.OnStop(self.exitCallback())
 
-	{{if .HasViper}} self.httpModule.Init(self, viper.GetString("App.Addr"), 5, router.InitRouter()) {{ else }}
+	{{if .HasViper}} self.httpModule.Init(self, viper.GetString("App.Addr"), viper.GetInt("App.Timeout"), router.InitRouter()) {{ else }}
 	self.httpModule.Init(self, {{ .ServerAddr}}, {{ .Timeout}}, router.InitRouter()) {{end}}
 	err = self.httpModule.Start()
 
 	return
 }
 
+// Stop 服务管理器停止服务时调用
+func (self *{{ .ServerName}}) Stop(ctx context.Context) error {
+	return self.httpModule.Stop(ctx)
+}
+
+// Ready 服务管理器检查服务是否就绪时调用
+func (self *{{ .ServerName}}) Ready(ctx context.Context) error {
+	return self.httpModule.Ready(ctx)
+}
+
 // TODO 添加回调函数, 无逻辑可直接删除这个方法
 func (self *{{ .ServerName}}) exitCallback() *gzutil.OrderlyMap {
 	callback := gzutil.NewOrderlyMap()
@@ -76,9 +88,9 @@ func (self *generator) GenServer() (err error) {
 	data := map[string]interface{}{
 		"PackageName":       self.serverPackageName,
 		"ServerName":        serverName,
-		"ServerAddr":        "base.Config.App.Addr",
+		"ServerAddr":        "base.GetConfig().App.Addr",
 		"HasViper":          false,
-		"Timeout":           "base.Config.App.Timeout",
+		"Timeout":           "base.GetConfig().App.Timeout",
 		"RouterPackagePath": filepath.Join(self.packageName, self.routerPackagePath),
 	}
 	if err = contentTmpl.Execute(&builder, data); err != nil {
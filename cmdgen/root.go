@@ -12,7 +12,7 @@ func init() {
 	genCmd.AddCommand(genapi.CmdGen)
 	genCmd.AddCommand(genmodel.CmdGen)
 	genCmd.AddCommand(gencurd.CmdGen)
-	gzconsole.Register(60, genCmd)
+	gzconsole.Register(genCmd)
 }
 
 var genCmd = &cobra.Command{
@@ -1,20 +1,33 @@
 package gzconsole
 
 import (
-	"sort"
+	"fmt"
+	"sync/atomic"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
+// AllModules 表示依赖所有其他已注册的模块, 用于必须最后启动的任务(如服务管理器)
+const AllModules = "*"
+
 type startupTask struct {
-	Priority int
-	Name     string
-	Cmd      *cobra.Command
+	Name      string
+	Cmd       *cobra.Command
+	DependsOn []string
+	loaded    atomic.Bool
+}
+
+// ModuleInfo 已注册模块的信息, Loaded 表示模块已经启动成功
+type ModuleInfo struct {
+	Name      string   `json:"name"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+	Loaded    bool     `json:"loaded"`
 }
 
 var (
-	startupTasks []startupTask
+	startupTasks []*startupTask
 	Echo         *zap.SugaredLogger
 	RootCmd      = &cobra.Command{
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -23,24 +36,60 @@ var (
 	}
 )
 
-func Register(priority int, cmd *cobra.Command) {
+// Register 注册一个启动任务, 模块名为 cmd.Name(), dependsOn 为它所依赖的模块名
+// 例如: gzconsole.Register(casbinCmd, "db") 表示 casbin 模块需要在 db 模块启动之后才能启动
+// 同一层的模块会并发执行 RunE, 不要在其中调用 viper.SetDefault 等修改全局状态的方法, 默认值应在 init 中设置
+func Register(cmd *cobra.Command, dependsOn ...string) {
 	RootCmd.AddCommand(cmd)
-	startupTasks = append(startupTasks, startupTask{
-		Priority: priority,
-		Name:     cmd.Name(),
-		Cmd:      cmd,
+	startupTasks = append(startupTasks, &startupTask{
+		Name:      cmd.Name(),
+		Cmd:       cmd,
+		DependsOn: dependsOn,
 	})
 }
 
+// Modules 按注册顺序返回所有模块及其启动状态
+func Modules() []ModuleInfo {
+	modules := make([]ModuleInfo, 0, len(startupTasks))
+	for _, task := range startupTasks {
+		modules = append(modules, ModuleInfo{
+			Name:      task.Name,
+			DependsOn: task.DependsOn,
+			Loaded:    task.loaded.Load(),
+		})
+	}
+
+	return modules
+}
+
 func runStartupTasks() error {
-	// 1. 根据优先级排序，数字越大越先执行
-	sort.SliceStable(startupTasks, func(i, j int) bool {
-		return startupTasks[i].Priority > startupTasks[j].Priority
-	})
+	// 1. 根据依赖关系分层, 同一层的模块之间互不依赖
+	levels, err := resolveStartupOrder(startupTasks)
+	if err != nil {
+		return err
+	}
 
-	// 2. 按顺序执行任务，任何一个任务失败，立即中止
-	for _, task := range startupTasks {
-		if err := task.Cmd.RunE(task.Cmd, []string{}); err != nil {
+	// 2. 逐层执行, 同一层内的模块并发启动, 任何一个任务失败, 立即中止并执行已注册的停止函数
+	for _, level := range levels {
+		var eg errgroup.Group
+		for _, task := range level {
+			if task.Cmd.RunE == nil {
+				task.loaded.Store(true)
+				continue
+			}
+			eg.Go(func() error {
+				if err := task.Cmd.RunE(task.Cmd, []string{}); err != nil {
+					return fmt.Errorf("模块 [%s] 启动失败: %w", task.Name, err)
+				}
+				task.loaded.Store(true)
+
+				return nil
+			})
+		}
+
+		if err := eg.Wait(); err != nil {
+			// 已经启动的模块(如数据库、Redis 连接)需要按逆序停止
+			_ = Shutdown()
 			return err
 		}
 	}
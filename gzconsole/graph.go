@@ -0,0 +1,132 @@
+package gzconsole
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolveStartupOrder 按依赖关系对启动任务做拓扑排序, 返回分层后的结果
+// 每一层中的任务只依赖于之前层中的任务, 因此同一层内的任务可以并发执行
+func resolveStartupOrder(tasks []*startupTask) ([][]*startupTask, error) {
+	taskMap := make(map[string]*startupTask, len(tasks))
+	for _, task := range tasks {
+		if _, exists := taskMap[task.Name]; exists {
+			return nil, fmt.Errorf("模块 [%s] 被重复注册", task.Name)
+		}
+		taskMap[task.Name] = task
+	}
+
+	// 1. 展开依赖并检查依赖是否存在
+	deps := make(map[string][]string, len(tasks))
+	for _, task := range tasks {
+		for _, dep := range task.DependsOn {
+			if dep == AllModules {
+				for _, other := range tasks {
+					if other.Name != task.Name && !dependsOnAll(other) {
+						deps[task.Name] = append(deps[task.Name], other.Name)
+					}
+				}
+				continue
+			}
+
+			if _, ok := taskMap[dep]; !ok {
+				return nil, fmt.Errorf("模块 [%s] 依赖模块 [%s], 但 [%s] 未加载, 请先导入对应的模块", task.Name, dep, dep)
+			}
+			deps[task.Name] = append(deps[task.Name], dep)
+		}
+	}
+
+	// 2. 分层拓扑排序, 层内保持注册顺序
+	inDegree := make(map[string]int, len(tasks))
+	dependents := make(map[string][]string, len(tasks))
+	for _, task := range tasks {
+		inDegree[task.Name] = len(deps[task.Name])
+		for _, dep := range deps[task.Name] {
+			dependents[dep] = append(dependents[dep], task.Name)
+		}
+	}
+
+	var levels [][]*startupTask
+	resolved := 0
+	for resolved < len(tasks) {
+		var level []*startupTask
+		for _, task := range tasks {
+			if inDegree[task.Name] == 0 {
+				level = append(level, task)
+			}
+		}
+		if len(level) == 0 {
+			return nil, fmt.Errorf("检测到模块循环依赖: %s", findCycle(tasks, deps, inDegree))
+		}
+
+		for _, task := range level {
+			inDegree[task.Name] = -1
+			for _, name := range dependents[task.Name] {
+				inDegree[name]--
+			}
+		}
+		resolved += len(level)
+		levels = append(levels, level)
+	}
+
+	return levels, nil
+}
+
+func dependsOnAll(task *startupTask) bool {
+	for _, dep := range task.DependsOn {
+		if dep == AllModules {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findCycle 在尚未排序的任务中找出一条依赖环, 用于错误提示
+func findCycle(tasks []*startupTask, deps map[string][]string, inDegree map[string]int) string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(tasks))
+	var path []string
+	var cycle []string
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range deps[name] {
+			if inDegree[dep] < 0 {
+				continue
+			}
+			switch state[dep] {
+			case visiting:
+				for i, n := range path {
+					if n == dep {
+						cycle = append(append(cycle, path[i:]...), dep)
+						break
+					}
+				}
+				return true
+			case unvisited:
+				if visit(dep) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+
+		return false
+	}
+
+	for _, task := range tasks {
+		if inDegree[task.Name] >= 0 && state[task.Name] == unvisited && visit(task.Name) {
+			break
+		}
+	}
+
+	return strings.Join(cycle, " -> ")
+}
@@ -0,0 +1,99 @@
+package gzconsole
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func task(name string, deps ...string) *startupTask {
+	return &startupTask{Name: name, DependsOn: deps}
+}
+
+func levelNames(levels [][]*startupTask) [][]string {
+	names := make([][]string, len(levels))
+	for i, level := range levels {
+		for _, task := range level {
+			names[i] = append(names[i], task.Name)
+		}
+	}
+
+	return names
+}
+
+func TestResolveStartupOrder(t *testing.T) {
+	cases := []struct {
+		name  string
+		tasks []*startupTask
+		want  [][]string
+	}{
+		{
+			name:  "没有依赖时全部在同一层, 保持注册顺序",
+			tasks: []*startupTask{task("c"), task("a"), task("b")},
+			want:  [][]string{{"c", "a", "b"}},
+		},
+		{
+			name:  "按依赖分层",
+			tasks: []*startupTask{task("d", "b", "c"), task("b", "a"), task("c", "a"), task("a")},
+			want:  [][]string{{"a"}, {"b", "c"}, {"d"}},
+		},
+		{
+			name:  "依赖所有模块的任务最后启动",
+			tasks: []*startupTask{task("server", AllModules), task("db"), task("http", "db")},
+			want:  [][]string{{"db"}, {"http"}, {"server"}},
+		},
+		{
+			name:  "多个依赖所有模块的任务互不依赖",
+			tasks: []*startupTask{task("server", AllModules), task("db"), task("report", AllModules)},
+			want:  [][]string{{"db"}, {"server", "report"}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			levels, err := resolveStartupOrder(c.tasks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := levelNames(levels); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("resolveStartupOrder = %v, 期望 %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestResolveStartupOrderErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		tasks []*startupTask
+		want  string
+	}{
+		{
+			name:  "依赖的模块未加载",
+			tasks: []*startupTask{task("http", "db")},
+			want:  "模块 [http] 依赖模块 [db], 但 [db] 未加载",
+		},
+		{
+			name:  "模块重复注册",
+			tasks: []*startupTask{task("db"), task("db")},
+			want:  "模块 [db] 被重复注册",
+		},
+		{
+			name:  "依赖自身",
+			tasks: []*startupTask{task("db", "db")},
+			want:  "检测到模块循环依赖: db -> db",
+		},
+		{
+			name:  "报告的循环路径不包含环外的模块",
+			tasks: []*startupTask{task("log"), task("a", "b"), task("b", "c"), task("c", "b"), task("server", AllModules)},
+			want:  "检测到模块循环依赖: b -> c -> b",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := resolveStartupOrder(c.tasks)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("错误为 %v, 期望包含 %q", err, c.want)
+			}
+		})
+	}
+}
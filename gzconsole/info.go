@@ -44,7 +44,7 @@ type bannerData struct {
 }
 
 func Show(commands, globalCommands []CommandInfo) {
-	fmt.Println("\n")
+	fmt.Print("\n\n")
 	title := figure.NewFigure("Gin Starter", "", true)
 	color.New(color.FgHiGreen).Println(title.String())
 
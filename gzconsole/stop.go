@@ -0,0 +1,85 @@
+package gzconsole
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultStopTimeout 模块停止函数默认的超时时间
+var DefaultStopTimeout = 5 * time.Second
+
+// StopFunc 模块的停止函数, ctx 会在超时后被取消
+type StopFunc func(ctx context.Context) error
+
+type stopTask struct {
+	Name    string
+	Fn      StopFunc
+	Timeout time.Duration
+}
+
+var (
+	stopMu    sync.Mutex
+	stopTasks []stopTask
+)
+
+// RegisterStop 为模块注册停止函数, 一般在模块启动成功后调用
+// 进程退出时会按注册顺序的逆序执行, 即先启动的模块后停止
+func RegisterStop(name string, fn StopFunc, timeout ...time.Duration) {
+	task := stopTask{
+		Name:    name,
+		Fn:      fn,
+		Timeout: DefaultStopTimeout,
+	}
+	if len(timeout) > 0 && timeout[0] > 0 {
+		task.Timeout = timeout[0]
+	}
+
+	stopMu.Lock()
+	defer stopMu.Unlock()
+	stopTasks = append(stopTasks, task)
+}
+
+// Shutdown 按注册顺序的逆序执行所有模块的停止函数, 某个模块停止失败或超时不会影响其他模块
+func Shutdown() error {
+	stopMu.Lock()
+	tasks := stopTasks
+	stopTasks = nil
+	stopMu.Unlock()
+
+	var errs []error
+	for i := len(tasks) - 1; i >= 0; i-- {
+		if err := runStopTask(tasks[i]); err != nil {
+			Echo.Warnf("⚠️  警告: 模块 [%s] 停止失败: %s\n", tasks[i].Name, err)
+			errs = append(errs, fmt.Errorf("模块 [%s]: %w", tasks[i].Name, err))
+			continue
+		}
+		Echo.Infof("✅  提示: 模块 [%s] 已停止\n", tasks[i].Name)
+	}
+
+	return errors.Join(errs...)
+}
+
+func runStopTask(task stopTask) error {
+	ctx, cancel := context.WithTimeout(context.Background(), task.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- task.Fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("超过 %s 仍未完成", task.Timeout)
+	}
+}
@@ -0,0 +1,180 @@
+package adminmodule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzgrace"
+	"github.com/w01fb0ss/gin-starter/pkg/gzmiddleware"
+)
+
+func init() {
+	gzconsole.Register(adminCmd)
+	base.RegisterConfigValidator(validateConfig)
+}
+
+// validateConfig 校验 `admin.addr` 配置
+func validateConfig(conf *base.BaseConfig, issues *base.ConfigIssues) {
+	if conf.Admin.Addr == "" {
+		return
+	}
+	if _, _, err := net.SplitHostPort(conf.Admin.Addr); err != nil {
+		issues.AddError("admin.addr", "%q 不是有效的监听地址, 示例: 127.0.0.1:9090", conf.Admin.Addr)
+	}
+}
+
+var adminCmd = &cobra.Command{
+	Use:    "admin",
+	Short:  "Init Admin Server",
+	Long:   `加载管理服务模块, 配置 admin.addr 后在单独的地址提供 pprof、运行信息、路由列表等诊断接口, 访问控制见 gzmiddleware.AdminAuth`,
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr := viper.GetString("Admin.Addr")
+		if addr == "" {
+			gzconsole.Echo.Info("ℹ️ 提示: [Admin] 未配置 admin.addr, 不启动管理服务\n")
+			return nil
+		}
+
+		base.RegisterService(newServer(cmd.Name(), addr))
+		gzconsole.Echo.Infof("✅  提示: [Admin] 模块加载成功, 管理服务将监听: %s\n", addr)
+
+		return nil
+	},
+}
+
+// adminServer 管理服务, 由服务管理器启动和停止
+type adminServer struct {
+	name   string
+	addr   string
+	server *http.Server
+	ready  atomic.Bool
+}
+
+func newServer(name, addr string) *adminServer {
+	return &adminServer{
+		name: name,
+		addr: addr,
+		server: &http.Server{
+			Addr:              addr,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+func (self *adminServer) OnStart() error {
+	ln, err := gzgrace.Listen(self.name, "tcp", self.addr, func() (net.Listener, error) {
+		return net.Listen("tcp", self.addr)
+	})
+	if err != nil {
+		return fmt.Errorf("管理服务监听 %s 失败: %s", self.addr, err)
+	}
+
+	// 路由在启动时创建, 此时业务路由已经设置好 gin 的运行模式
+	self.server.Handler = newRouter()
+	self.ready.Store(true)
+	gzconsole.Echo.Infof("✅  提示: 管理服务启动成功, 地址为: http://%s\n", ln.Addr())
+	if err = self.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// stopGrace 停止时等待请求完成的时间, pprof 的 profile、trace 会按 seconds 参数持续采集, 超时后直接断开
+const stopGrace = 3 * time.Second
+
+// Stop 实现 base.IServiceStop, 最多等待 stopGrace, 不占用其他服务的停止时间
+func (self *adminServer) Stop(ctx context.Context) error {
+	self.ready.Store(false)
+
+	ctx, cancel := context.WithTimeout(ctx, stopGrace)
+	defer cancel()
+	if err := self.server.Shutdown(ctx); err != nil {
+		return self.server.Close()
+	}
+
+	return nil
+}
+
+// Ready 实现 base.IServiceReady
+func (self *adminServer) Ready(ctx context.Context) error {
+	if !self.ready.Load() {
+		return errors.New("管理服务未就绪")
+	}
+
+	return nil
+}
+
+// newRouter 管理服务的路由, 所有接口都需要通过 gzmiddleware.AdminAuth
+func newRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery(), gzmiddleware.Begin(), gzmiddleware.AdminAuth())
+
+	r.GET("/", indexHandler(r))
+	r.GET("/build", buildHandler)
+	r.GET("/runtime", runtimeHandler)
+	r.GET("/routes", routesHandler)
+	r.GET("/modules", modulesHandler)
+	r.GET("/dbs", dbsHandler)
+	r.GET("/goroutines", goroutinesHandler)
+	r.GET("/log/level", base.LogLevelHandler())
+	r.PUT("/log/level", base.LogLevelHandler())
+	r.GET("/log/tail", base.LogTailHandler())
+	r.GET("/debug/pprof/*name", pprofHandler)
+	r.POST("/debug/pprof/*name", pprofHandler)
+
+	return r
+}
+
+// indexHandler 列出管理服务的所有接口
+func indexHandler(r *gin.Engine) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var paths []string
+		for _, route := range r.Routes() {
+			paths = append(paths, route.Method+" "+route.Path)
+		}
+		sort.Strings(paths)
+
+		base.Success(ctx, paths)
+	}
+}
+
+// pprofHandler 与 net/http/pprof 注册到 http.DefaultServeMux 的接口相同, 如 /debug/pprof/heap、/debug/pprof/profile?seconds=30
+func pprofHandler(ctx *gin.Context) {
+	switch ctx.Param("name") {
+	case "/cmdline":
+		pprof.Cmdline(ctx.Writer, ctx.Request)
+	case "/profile":
+		pprof.Profile(ctx.Writer, ctx.Request)
+	case "/symbol":
+		pprof.Symbol(ctx.Writer, ctx.Request)
+	case "/trace":
+		pprof.Trace(ctx.Writer, ctx.Request)
+	default:
+		pprof.Index(ctx.Writer, ctx.Request)
+	}
+}
+
+// goroutinesHandler 输出所有协程的调用栈, 参数 debug 默认为 2, 与 panic 时的输出格式相同, 为 1 时按调用栈合并
+func goroutinesHandler(ctx *gin.Context) {
+	debug, err := strconv.Atoi(ctx.DefaultQuery("debug", "2"))
+	if err != nil {
+		debug = 2
+	}
+
+	ctx.Header("Content-Type", "text/plain; charset=utf-8")
+	_ = writeGoroutines(ctx.Writer, debug)
+}
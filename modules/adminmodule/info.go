@@ -0,0 +1,153 @@
+package adminmodule
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"gorm.io/gorm"
+)
+
+// 编译时通过 -ldflags 注入的版本信息, 为空时从 debug.ReadBuildInfo 中读取
+//
+//	go build -ldflags "-X github.com/w01fb0ss/gin-starter/modules/adminmodule.Version=v1.0.0 -X github.com/w01fb0ss/gin-starter/modules/adminmodule.Commit=$(git rev-parse HEAD)"
+var (
+	Version   string
+	Commit    string
+	BuildTime string
+)
+
+var startedAt = time.Now()
+
+// BuildInfo 构建信息
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	Modified  bool   `json:"modified"` // 编译时工作区有未提交的修改
+	GoVersion string `json:"goVersion"`
+	Path      string `json:"path"`
+	Os        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// RuntimeInfo 进程运行信息, 内存单位为字节
+type RuntimeInfo struct {
+	Pid        int       `json:"pid"`
+	StartedAt  time.Time `json:"startedAt"`
+	Uptime     string    `json:"uptime"`
+	Goroutines int       `json:"goroutines"`
+	GoMaxProcs int       `json:"goMaxProcs"`
+	NumCpu     int       `json:"numCpu"`
+	HeapAlloc  uint64    `json:"heapAlloc"`
+	HeapInuse  uint64    `json:"heapInuse"`
+	Sys        uint64    `json:"sys"`
+	NumGc      uint32    `json:"numGc"`
+	PauseTotal string    `json:"pauseTotal"`
+}
+
+// DbInfo 已初始化的数据库实例
+type DbInfo struct {
+	Name string `json:"name"`
+	Gorm bool   `json:"gorm"`
+	Sqlx bool   `json:"sqlx"`
+}
+
+// GetBuildInfo 返回构建信息, -ldflags 注入的值优先
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Os:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Path = build.Main.Path
+	if info.Version == "" {
+		info.Version = build.Main.Version
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+}
+
+// GetRuntimeInfo 返回进程运行信息, 读取内存统计时会短暂暂停所有协程
+func GetRuntimeInfo() RuntimeInfo {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return RuntimeInfo{
+		Pid:        os.Getpid(),
+		StartedAt:  startedAt,
+		Uptime:     time.Since(startedAt).Truncate(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+		GoMaxProcs: runtime.GOMAXPROCS(0),
+		NumCpu:     runtime.NumCPU(),
+		HeapAlloc:  mem.HeapAlloc,
+		HeapInuse:  mem.HeapInuse,
+		Sys:        mem.Sys,
+		NumGc:      mem.NumGC,
+		PauseTotal: time.Duration(mem.PauseTotalNs).String(),
+	}
+}
+
+// GetDbs 按名称排序返回已初始化的数据库实例
+func GetDbs() []DbInfo {
+	dbs := make([]DbInfo, 0)
+	base.RangeDb(func(name string, gdb *gorm.DB, sdb *sqlx.DB) bool {
+		dbs = append(dbs, DbInfo{Name: name, Gorm: gdb != nil, Sqlx: sdb != nil})
+		return true
+	})
+	sort.Slice(dbs, func(i, j int) bool {
+		return dbs[i].Name < dbs[j].Name
+	})
+
+	return dbs
+}
+
+func writeGoroutines(w io.Writer, debug int) error {
+	return pprof.Lookup("goroutine").WriteTo(w, debug)
+}
+
+func buildHandler(ctx *gin.Context) {
+	base.Success(ctx, GetBuildInfo())
+}
+
+func runtimeHandler(ctx *gin.Context) {
+	base.Success(ctx, GetRuntimeInfo())
+}
+
+func modulesHandler(ctx *gin.Context) {
+	base.Success(ctx, gzconsole.Modules())
+}
+
+func dbsHandler(ctx *gin.Context) {
+	base.Success(ctx, GetDbs())
+}
@@ -0,0 +1,98 @@
+package adminmodule
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/modules/httpmodule"
+)
+
+// RouteInfo 一个路由及其完整的处理链, Handlers 中最后一个为路由的处理函数, 之前的为中间件
+type RouteInfo struct {
+	Method   string   `json:"method"`
+	Path     string   `json:"path"`
+	Handler  string   `json:"handler"`
+	Handlers []string `json:"handlers"`
+}
+
+// GetRoutes 返回 httpmodule 中所有服务的路由, key 为服务名, 见 httpmodule.Engines
+func GetRoutes() map[string][]RouteInfo {
+	result := make(map[string][]RouteInfo)
+	for name, engine := range httpmodule.Engines() {
+		result[name] = Routes(engine)
+	}
+
+	return result
+}
+
+// Routes 返回 engine 中的所有路由, 按路径和方法排序
+func Routes(engine *gin.Engine) []RouteInfo {
+	chains := handlerChains(engine)
+	routes := make([]RouteInfo, 0)
+	for _, route := range engine.Routes() {
+		handlers := chains[route.Method+" "+route.Path]
+		if len(handlers) == 0 {
+			handlers = []string{route.Handler}
+		}
+		routes = append(routes, RouteInfo{
+			Method:   route.Method,
+			Path:     route.Path,
+			Handler:  route.Handler,
+			Handlers: handlers,
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+// handlerChains gin 只提供了路由的最后一个处理函数, 这里通过反射读取路由树中每个路由的完整处理链,
+// key 为 方法 路径, 与 engine.Routes() 的遍历方式相同; gin 内部结构变化导致读取失败时返回已读取的部分
+func handlerChains(engine *gin.Engine) (chains map[string][]string) {
+	chains = make(map[string][]string)
+	defer func() {
+		_ = recover()
+	}()
+
+	trees := reflect.ValueOf(engine).Elem().FieldByName("trees")
+	for i := 0; i < trees.Len(); i++ {
+		tree := trees.Index(i)
+		walkNode(chains, tree.FieldByName("method").String(), "", tree.FieldByName("root"))
+	}
+
+	return chains
+}
+
+func walkNode(chains map[string][]string, method, path string, node reflect.Value) {
+	if node.IsNil() {
+		return
+	}
+	node = node.Elem()
+	path += node.FieldByName("path").String()
+
+	handlers := node.FieldByName("handlers")
+	if handlers.Len() > 0 {
+		names := make([]string, handlers.Len())
+		for i := range names {
+			names[i] = runtime.FuncForPC(handlers.Index(i).Pointer()).Name()
+		}
+		chains[method+" "+path] = names
+	}
+
+	children := node.FieldByName("children")
+	for i := 0; i < children.Len(); i++ {
+		walkNode(chains, method, path, children.Index(i))
+	}
+}
+
+func routesHandler(ctx *gin.Context) {
+	base.Success(ctx, GetRoutes())
+}
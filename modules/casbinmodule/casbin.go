@@ -1,6 +1,7 @@
 package casbinmodule
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/casbin/casbin/v2"
@@ -15,7 +16,26 @@ import (
 )
 
 func init() {
-	gzconsole.Register(5, casbinCmd)
+	gzconsole.Register(casbinCmd, "db")
+	base.RegisterConfigValidator(validateConfig)
+}
+
+// validateConfig 校验 `casbin` 配置
+func validateConfig(conf *base.BaseConfig, issues *base.ConfigIssues) {
+	if conf.Casbin.ModePath == "" {
+		issues.AddError("casbin.modePath", "已加载 Casbin 模块, 但未配置模型文件路径")
+	} else if exists, _ := gzutil.FileIsExist(conf.Casbin.ModePath); !exists {
+		issues.AddError("casbin.modePath", "模型文件 %s 不存在", conf.Casbin.ModePath)
+	}
+
+	// 只配置了一个数据库时, 它同时也是 `default`
+	dbName := gzutil.Ternary(conf.Casbin.DbName == "", "default", conf.Casbin.DbName)
+	for _, db := range conf.Db {
+		if db.Name == dbName || (dbName == "default" && len(conf.Db) == 1) {
+			return
+		}
+	}
+	issues.AddError("casbin.dbName", "数据库 %q 未在 databases 中配置", dbName)
 }
 
 var casbinCmd = &cobra.Command{
@@ -60,6 +80,14 @@ func initCasbin() error {
 	_ = syncedEnforcer.LoadPolicy()
 
 	base.Casbin = syncedEnforcer
+	// 策略保存在数据库中, 数据库不可用时无法重新加载策略
+	if sqlDB, err := db.DB(); err == nil {
+		base.RegisterHealthCheck("casbin", sqlDB.PingContext)
+	}
+	gzconsole.RegisterStop("casbin", func(ctx context.Context) error {
+		syncedEnforcer.StopAutoLoadPolicy()
+		return nil
+	})
 	gzconsole.Echo.Info("✅  提示: [Casbin] 模块加载成功, 你可以使用 `base.Casbin` 进行权限操作\n")
 	return nil
 }
@@ -1,6 +1,7 @@
 package dbmodule
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -20,7 +21,8 @@ const (
 )
 
 func init() {
-	gzconsole.Register(10, dbCmd)
+	gzconsole.Register(dbCmd)
+	base.RegisterConfigValidator(validateConfig)
 }
 
 var dbCmd = &cobra.Command{
@@ -85,7 +87,13 @@ func initFunc() error {
 			if err != nil {
 				return err
 			}
+			sqlDB, err := gdb.DB()
+			if err != nil {
+				return fmt.Errorf("获取数据库 [%s] 的连接池失败: %s", dbConf.Name, err)
+			}
 			base.SetDb(dbConf.Name, gdb, nil)
+			registerStop(dbConf.Name, sqlDB.Close)
+			base.RegisterHealthCheck("db:"+dbConf.Name, sqlDB.PingContext)
 			if isDefault {
 				base.SetDb("default", gdb, nil)
 			}
@@ -96,6 +104,8 @@ func initFunc() error {
 				return err
 			}
 			base.SetDb(dbConf.Name, nil, sdb)
+			registerStop(dbConf.Name, sdb.Close)
+			base.RegisterHealthCheck("db:"+dbConf.Name, sdb.PingContext)
 			if isDefault {
 				base.SetDb("default", nil, sdb)
 			}
@@ -107,3 +117,10 @@ func initFunc() error {
 
 	return nil
 }
+
+// registerStop 进程退出时关闭数据库连接池, `default` 只是别名, 不重复关闭
+func registerStop(name string, closeFunc func() error) {
+	gzconsole.RegisterStop("db:"+name, func(ctx context.Context) error {
+		return closeFunc()
+	})
+}
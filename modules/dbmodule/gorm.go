@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/spf13/viper"
+	"github.com/w01fb0ss/gin-starter/pkg/gztrace"
 	"gopkg.in/natefinch/lumberjack.v2"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -67,6 +68,11 @@ func newGormDB(conf *dbConfig) (*gorm.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("数据库连接失败: %s", err)
 	}
+	if viper.GetBool("Trace.Enable") {
+		if err = db.Use(gztrace.GormPlugin(dbSystem(driverArr[0]), conf.Name)); err != nil {
+			return nil, fmt.Errorf("注册链路追踪失败: %s", err)
+		}
+	}
 
 	sqlDB, _ := db.DB()
 	sqlDB.SetMaxIdleConns(conf.MaxIdleConn)
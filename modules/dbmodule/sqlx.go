@@ -2,16 +2,20 @@ package dbmodule
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/spf13/viper"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 )
 
 func newSqlxDB(conf *dbConfig) (*sqlx.DB, error) {
-	db, err := sqlx.Connect(conf.Driver, conf.Dsn)
+	db, err := openSqlx(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -26,3 +30,34 @@ func newSqlxDB(conf *dbConfig) (*sqlx.DB, error) {
 
 	return db, nil
 }
+
+// openSqlx 开启链路追踪时通过 otelsql 包装驱动, 为每条 SQL 创建 span
+func openSqlx(conf *dbConfig) (*sqlx.DB, error) {
+	if !viper.GetBool("Trace.Enable") {
+		return sqlx.Open(conf.Driver, conf.Dsn)
+	}
+
+	db, err := otelsql.Open(conf.Driver, conf.Dsn,
+		otelsql.WithAttributes(semconv.DBSystemNameKey.String(dbSystem(conf.Driver)), semconv.DBNamespace(conf.Name)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true, DisableErrSkip: true}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlx.NewDb(db, conf.Driver), nil
+}
+
+// dbSystem 将驱动名称转换为 OpenTelemetry 约定的数据库类型
+func dbSystem(driver string) string {
+	switch driver = strings.ToLower(driver); driver {
+	case "postgres", DbTypePostgresql:
+		return "postgresql"
+	case "sqlite3", DbTypeSqlite:
+		return "sqlite"
+	case DbTypeSqlserver, "mssql":
+		return "microsoft.sql_server"
+	default:
+		return driver
+	}
+}
@@ -0,0 +1,79 @@
+package dbmodule
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+)
+
+// gormDrivers GORM 支持的数据库类型, 配置中 `driver` 以 `_` 分隔的第一段
+var gormDrivers = []string{DbTypeMysql, DbTypePostgresql, DbTypeSqlite, DbTypeSqlserver}
+
+// validateConfig 校验 `databases` 配置
+func validateConfig(conf *base.BaseConfig, issues *base.ConfigIssues) {
+	if len(conf.Db) == 0 {
+		issues.AddError("databases", "已加载 DB 模块, 但未配置任何数据库")
+		return
+	}
+
+	names := make(map[string]bool, len(conf.Db))
+	for i, db := range conf.Db {
+		key := fmt.Sprintf("databases[%d]", i)
+		if db.Name == "" {
+			issues.AddError(key+".name", "不能为空")
+		} else if names[db.Name] {
+			issues.AddError(key+".name", "数据库名称 %q 重复", db.Name)
+		}
+		names[db.Name] = true
+
+		driver := strings.ToLower(strings.Split(db.Driver, "_")[0])
+		if db.UseGorm && !gzutil.InArray(driver, gormDrivers) {
+			issues.AddError(key+".driver", "GORM 不支持的数据库类型 %q, 可选值: %s", db.Driver, strings.Join(gormDrivers, "、"))
+			continue
+		}
+		if !db.UseGorm && !gzutil.InArray(db.Driver, sql.Drivers()) {
+			issues.AddError(key+".driver", "sqlx 不支持的数据库驱动 %q, 可选值: %s", db.Driver, strings.Join(sql.Drivers(), "、"))
+			continue
+		}
+
+		if db.Dsn == "" {
+			issues.AddError(key+".dsn", "不能为空")
+		} else if err := parseDsn(driver, db.Dsn); err != nil {
+			issues.AddError(key+".dsn", "无法解析: %s", err)
+		}
+
+		if db.MaxConn > 0 && db.MaxIdleConn > db.MaxConn {
+			issues.AddWarn(key+".maxIdleConn", "大于 maxConn(%d), 超出的部分不会生效", db.MaxConn)
+		}
+	}
+}
+
+// parseDsn 按照驱动类型解析 DSN, 只做格式校验, 不会建立连接
+func parseDsn(driver, dsn string) error {
+	switch driver {
+	case DbTypeMysql:
+		_, err := mysql.ParseDSN(dsn)
+		return err
+	case DbTypePostgresql, "postgres":
+		if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+			_, err := pq.ParseURL(dsn)
+			return err
+		}
+		if !strings.Contains(dsn, "=") {
+			return fmt.Errorf("应为 URL 或 key=value 格式")
+		}
+	case DbTypeSqlserver:
+		if strings.HasPrefix(dsn, "sqlserver://") {
+			_, err := url.Parse(dsn)
+			return err
+		}
+	}
+
+	return nil
+}
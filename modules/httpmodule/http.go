@@ -2,43 +2,254 @@ package httpmodule
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/w01fb0ss/gin-starter/base"
 	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzgrace"
+	"github.com/w01fb0ss/gin-starter/pkg/gztls"
 	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+	"go.uber.org/zap"
 )
 
+func init() {
+	base.RegisterConfigValidator(validateConfig)
+}
+
+// validateConfig 校验 `app.server` 配置
+func validateConfig(conf *base.BaseConfig, issues *base.ConfigIssues) {
+	server := conf.App.Server
+	if server.ReadTimeout < 0 || server.ReadHeaderTimeout < 0 || server.WriteTimeout < 0 || server.IdleTimeout < 0 || server.MaxHeaderBytes < 0 || server.PreStopDelay < 0 {
+		issues.AddError("app.server", "readTimeout、readHeaderTimeout、writeTimeout、idleTimeout、maxHeaderBytes、preStopDelay 不能小于 0")
+	}
+	shutdownTimeout := gzutil.Ternary(conf.App.ShutdownTimeout > 0, time.Duration(conf.App.ShutdownTimeout)*time.Second, base.DefaultShutdownTimeout)
+	if server.PreStopDelay > 0 && time.Duration(server.PreStopDelay)*time.Second >= shutdownTimeout {
+		issues.AddError("app.server.preStopDelay", "必须小于 app.shutdownTimeout(%s), 否则没有时间等待进行中的请求", shutdownTimeout)
+	}
+	for i, listener := range server.Listeners {
+		key := fmt.Sprintf("app.server.listeners[%d]", i)
+		switch listener.Network {
+		case "", "tcp", "tcp4", "tcp6", "unix":
+		default:
+			issues.AddError(key+".network", "不支持的类型 %q, 可选值: tcp、tcp4、tcp6、unix", listener.Network)
+		}
+		if listener.Addr == "" {
+			issues.AddError(key+".addr", "不能为空")
+		}
+		if listener.Handler == "" {
+			issues.AddError(key+".handler", "不能为空, %s 表示使用服务的全部路由, 其他值需要通过 IHttp.SetHandler 注册", EngineHandler)
+		}
+		if listener.Service == "" && conf.App.Addr == "" {
+			issues.AddError(key+".service", "未配置 app.addr 时必须指定所属的服务")
+		}
+	}
+
+	tlsConf := server.Tls
+	if tlsConf.Enable && len(tlsConf.Certs) == 0 {
+		issues.AddError("app.server.tls.certs", "开启 TLS 时至少需要配置一个证书")
+	}
+	for i, cert := range tlsConf.Certs {
+		if cert.CertFile == "" || cert.KeyFile == "" {
+			issues.AddError(fmt.Sprintf("app.server.tls.certs[%d]", i), "certFile 和 keyFile 不能为空")
+		}
+	}
+	if _, err := gztls.ParseVersion(tlsConf.MinVersion); err != nil {
+		issues.AddError("app.server.tls.minVersion", "%s", err)
+	}
+	if _, err := gztls.ParseCipherSuites(tlsConf.CipherSuites); err != nil {
+		issues.AddError("app.server.tls.cipherSuites", "%s", err)
+	}
+	if _, err := gztls.ParseClientAuth(tlsConf.ClientAuth); err != nil {
+		issues.AddError("app.server.tls.clientAuth", "%s", err)
+	}
+}
+
+// Listener 一个监听地址, 一个 IHttp 可以同时监听多个地址, 例如对外的业务端口和对内的管理端口
+type Listener struct {
+	Name     string       // 名称, 用于输出提示
+	Network  string       // tcp(默认)、tcp4、tcp6、unix
+	Addr     string       // 监听地址, network 为 unix 时为 socket 文件路径
+	Handler  http.Handler // 为空时使用 IHttp 的路由
+	Listener net.Listener // 已经打开的监听, 设置后忽略 Network 和 Addr, 一般用于测试
+}
+
+// ServerOptions http.Server 的参数, 未调用 SetOptions 时读取 app.server 配置
+type ServerOptions struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	DisableKeepAlive  bool
+	TcpKeepAlive      time.Duration // 小于 0 时关闭 TCP keep-alive
+	H2c               bool          // 非 TLS 时同时支持 HTTP/2(prior knowledge)
+	PreStopDelay      time.Duration // 停止时标记为未就绪后, 继续处理请求的时间, 等待负载均衡摘除实例
+}
+
+// EngineHandler app.server.listeners 中 handler 为该值时使用服务的 gin.Engine
+const EngineHandler = "engine"
+
+// pendingConnWait 停止时等待已建立的连接发送请求的最长时间
+const pendingConnWait = time.Second
+
+// engines 服务使用的 gin.Engine, 用于 adminmodule 列出路由
+var engines sync.Map
+
+// Engines 返回所有服务使用的 gin.Engine, key 为服务名, AddListener 指定的 gin.Engine 的 key 为 服务名/监听名
+func Engines() map[string]*gin.Engine {
+	result := make(map[string]*gin.Engine)
+	engines.Range(func(key, value any) bool {
+		result[key.(string)] = value.(*gin.Engine)
+		return true
+	})
+
+	return result
+}
+
 type IHttp struct {
 	*gin.Engine
 
 	name       string
 	listenAddr string
 	timeout    time.Duration
-	srv        *http.Server
+	options    *ServerOptions
+	listeners  []Listener
+	handlers   map[string]http.Handler
+	servers    []*http.Server
+	lns        []net.Listener
+	tlsConfig  *tls.Config
 	tls        bool
+	pending    sync.Map // 已建立连接但还没有读到请求的连接
 
 	stopCallback *gzutil.OrderlyMap
+	initOnce     sync.Once
 	exit         chan error
+	stop         chan struct{}
+	stopOnce     sync.Once
+	stopCtx      atomic.Pointer[context.Context] // Stop 传入的上下文, 停止时等待请求不超过它的截止时间
+	done         chan struct{}
+	started      atomic.Bool
+	ready        atomic.Bool
+	draining     atomic.Bool
+	serving      sync.WaitGroup
+	inFlight     atomic.Int64
+	onShutdown   []func()
 }
 
+// Init 设置服务的地址和路由, timeout 为停止时等待进行中的请求的最长时间(秒), 小于等于 0 时使用 App.ShutdownTimeout
 func (self *IHttp) Init(caller interface{}, addr string, timeout int, engine *gin.Engine) {
+	self.lazyInit()
 	self.name = gzutil.GetCallerName(caller)
-	self.exit = make(chan error)
 	self.listenAddr = addr
-	self.timeout = time.Duration(timeout) * time.Second
+	self.timeout = gzutil.Ternary(timeout > 0, time.Duration(timeout)*time.Second, base.ShutdownTimeout())
 	self.Engine = engine
 	gzutil.ServerAddr = addr
+	if engine != nil {
+		engines.Store(self.name, engine)
+	}
+}
+
+// lazyInit 创建停止使用的 channel, 服务一般在 OnStart 中调用 Init, 此时服务管理器可能已经在调用 Stop 和 Ready
+func (self *IHttp) lazyInit() {
+	self.initOnce.Do(func() {
+		self.exit = make(chan error, 1)
+		self.stop = make(chan struct{})
+		self.done = make(chan struct{})
+	})
+}
+
+// SetOptions 设置 http.Server 的参数, 覆盖 app.server 配置, 需要在 Start 之前调用
+func (self *IHttp) SetOptions(options ServerOptions) {
+	self.options = &options
 }
 
+// SetTLSConfig 使用自定义的 tls.Config, 设置后 Start 使用 TLS, 覆盖 app.server.tls 配置, 需要在 Start 之前调用
+func (self *IHttp) SetTLSConfig(config *tls.Config) {
+	self.tlsConfig = config
+}
+
+// RegisterOnShutdown 注册停止接收新请求后调用的函数, 用于通知 websocket 等被接管(Hijack)的连接关闭,
+// 这类连接不会被 http.Server.Shutdown 等待, 需要在 Start 之前调用
+func (self *IHttp) RegisterOnShutdown(fn func()) {
+	self.onShutdown = append(self.onShutdown, fn)
+}
+
+// InFlight 返回正在处理的请求数
+func (self *IHttp) InFlight() int64 {
+	return self.inFlight.Load()
+}
+
+// AddListener 增加一个监听地址, 需要在 Start 之前调用
+//
+//	self.httpModule.AddListener(httpmodule.Listener{Name: "admin", Addr: "127.0.0.1:9090", Handler: adminRouter})
+func (self *IHttp) AddListener(listener Listener) {
+	self.listeners = append(self.listeners, listener)
+}
+
+// SetHandler 为 app.server.listeners 中 handler 为 name 的监听指定处理器, 需要在 Start 之前调用
+//
+//	self.httpModule.SetHandler("admin", adminRouter)
+func (self *IHttp) SetHandler(name string, handler http.Handler) {
+	if self.handlers == nil {
+		self.handlers = make(map[string]http.Handler)
+	}
+	self.handlers[name] = handler
+}
+
+// UseListener 使用已经打开的监听, 一般用于测试, 此时 Init 的 addr 可以为空
+//
+//	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+//	self.httpModule.UseListener(ln)
+func (self *IHttp) UseListener(ln net.Listener) {
+	self.AddListener(Listener{Name: "listener", Listener: ln})
+}
+
+// OnInit 根据 Init 的地址和 AddListener 添加的地址创建 http.Server
 func (self *IHttp) OnInit() {
-	self.srv = &http.Server{
-		Addr:    self.listenAddr,
-		Handler: self.Engine,
+	options := self.serverOptions()
+
+	if self.listenAddr != "" {
+		self.listeners = append([]Listener{{Name: "main", Network: networkOf(self.listenAddr), Addr: self.listenAddr}}, self.listeners...)
+	}
+
+	self.servers = make([]*http.Server, len(self.listeners))
+	for i, listener := range self.listeners {
+		handler := listener.Handler
+		if handler == nil {
+			handler = self.Engine
+		}
+		if engine, ok := handler.(*gin.Engine); ok && engine != self.Engine {
+			engines.Store(self.name+"/"+listener.Name, engine)
+		}
+		srv := &http.Server{
+			Addr:              listener.Addr,
+			Handler:           self.countRequests(handler),
+			ReadTimeout:       options.ReadTimeout,
+			ReadHeaderTimeout: options.ReadHeaderTimeout,
+			WriteTimeout:      options.WriteTimeout,
+			IdleTimeout:       options.IdleTimeout,
+			MaxHeaderBytes:    options.MaxHeaderBytes,
+		}
+		srv.SetKeepAlivesEnabled(!options.DisableKeepAlive)
+		srv.ConnState = self.trackConn
+		if options.H2c {
+			protocols := new(http.Protocols)
+			protocols.SetHTTP1(true)
+			protocols.SetHTTP2(true)
+			protocols.SetUnencryptedHTTP2(true)
+			srv.Protocols = protocols
+		}
+		self.servers[i] = srv
 	}
 }
 
@@ -46,59 +257,418 @@ func (self *IHttp) OnStop(data *gzutil.OrderlyMap) {
 	self.stopCallback = data
 }
 
+// Start 启动服务, 调用了 SetTLSConfig 或开启了 app.server.tls 时使用 TLS
 func (self *IHttp) Start() error {
-	self.OnInit()
-	go func() {
-		if err := self.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			gzconsole.Echo.Errorf("❌  错误: 服务启动异常 %s\n", err)
-			self.exit <- err
+	if !self.begin() {
+		return nil
+	}
+	tlsConfig := self.tlsConfig
+	if tlsConfig == nil && base.GetConfig().App.Server.Tls.Enable {
+		var err error
+		if tlsConfig, err = TLSConfig(); err != nil {
+			return self.startFailed(err)
 		}
-	}()
-
-	self.tls = false
-	gzutil.ServerIsTLS = false
-	gzconsole.Echo.Infof("✅  提示: 服务 %s 启动成功，地址为: %s\n", self.name, gzutil.GetServerAddr())
+	}
 
-	return self.running()
+	return self.serve(tlsConfig)
 }
 
+// StartTLS 使用指定的证书启动 TLS 服务, app.server.tls 中的证书和其他参数同样生效, 指定的证书作为默认证书
 func (self *IHttp) StartTLS(certFile, keyFile string) error {
+	if !self.begin() {
+		return nil
+	}
+	tlsConfig, err := newTLSConfig(&gztls.CertPair{CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		return self.startFailed(err)
+	}
+
+	return self.serve(tlsConfig)
+}
+
+// TLSConfig 按照 app.server.tls 创建 tls.Config, 证书文件变化后自动重新加载, 也可以用于 HTTP/3 等其他服务
+func TLSConfig() (*tls.Config, error) {
+	return newTLSConfig(nil)
+}
+
+func newTLSConfig(pair *gztls.CertPair) (*tls.Config, error) {
+	conf := base.GetConfig().App.Server.Tls
+	certs := make([]gztls.CertPair, 0, len(conf.Certs)+1)
+	if pair != nil {
+		certs = append(certs, *pair)
+	}
+	for _, cert := range conf.Certs {
+		certs = append(certs, gztls.CertPair{CertFile: cert.CertFile, KeyFile: cert.KeyFile})
+	}
+	interval := gzutil.Ternary(conf.ReloadInterval == 0, 60, conf.ReloadInterval)
+
+	return gztls.NewConfig(gztls.Options{
+		Certs:          certs,
+		MinVersion:     conf.MinVersion,
+		CipherSuites:   conf.CipherSuites,
+		ClientCa:       conf.ClientCa,
+		ClientAuth:     conf.ClientAuth,
+		ReloadInterval: time.Duration(max(interval, 0)) * time.Second,
+		OnReload: func(err error) {
+			if err != nil {
+				gzconsole.Echo.Warnf("⚠️  警告: 证书重新加载失败, 继续使用旧证书: %s\n", err)
+				base.Log.Warn("证书重新加载失败", zap.Error(err))
+				return
+			}
+			gzconsole.Echo.Info("✅  提示: 证书已重新加载\n")
+		},
+	})
+}
+
+// Stop 通知服务停止并等待关闭完成, 实现 base.IServiceStop
+func (self *IHttp) Stop(ctx context.Context) error {
+	self.lazyInit()
+	self.stopCtx.CompareAndSwap(nil, &ctx)
+	self.stopOnce.Do(func() {
+		close(self.stop)
+	})
+	// 还没有调用 Start, 之后调用时会直接返回
+	if !self.started.Load() {
+		return nil
+	}
+
+	select {
+	case <-self.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ready 所有地址监听成功后即为就绪, 开始停止后即为未就绪, 实现 base.IServiceReady
+func (self *IHttp) Ready(ctx context.Context) error {
+	if self.draining.Load() {
+		return errors.New("服务正在停止")
+	}
+	if !self.ready.Load() {
+		return errors.New("服务尚未就绪")
+	}
+
+	return nil
+}
+
+func (self *IHttp) serve(tlsConfig *tls.Config) error {
+	listeners, err := self.configListeners()
+	if err != nil {
+		return self.startFailed(err)
+	}
+	self.listeners = append(listeners, self.listeners...)
 	self.OnInit()
-	go func() {
-		if err := self.srv.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
-			gzconsole.Echo.Errorf("❌  错误: 服务启动异常 %s\n", err)
-			self.exit <- err
-		}
-	}()
+	lns, err := self.listen()
+	if err != nil {
+		return self.startFailed(err)
+	}
 
-	self.tls = true
-	gzutil.ServerIsTLS = true
-	gzconsole.Echo.Infof("✅  提示: 服务 %s 启动成功，地址为: %s\n", self.name, gzutil.GetServerAddr())
+	self.lns = lns
+	self.tls = tlsConfig != nil
+	gzutil.ServerIsTLS = self.tls
+	for i, ln := range lns {
+		srv := self.servers[i]
+		srv.TLSConfig = tlsConfig
+		self.serving.Add(1)
+		go func() {
+			defer self.serving.Done()
+			var err error
+			if tlsConfig != nil {
+				err = srv.ServeTLS(ln, "", "")
+			} else {
+				err = srv.Serve(ln)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+				gzconsole.Echo.Errorf("❌  错误: 服务启动异常 %s\n", err)
+				select {
+				case self.exit <- err:
+				default:
+				}
+			}
+		}()
+		gzconsole.Echo.Infof("✅  提示: 服务 %s 启动成功，地址为: %s\n", self.name, self.displayAddr(self.listeners[i], ln))
+	}
+	self.ready.Store(true)
 
 	return self.running()
 }
 
+// configListeners 返回 app.server.listeners 中属于当前服务的监听, 没有指定 service 的监听属于监听 app.addr 的服务
+func (self *IHttp) configListeners() ([]Listener, error) {
+	conf := base.GetConfig().App
+	var listeners []Listener
+	for _, listener := range conf.Server.Listeners {
+		if listener.Service != "" && listener.Service != self.name {
+			continue
+		}
+		if listener.Service == "" && (conf.Addr == "" || self.listenAddr != conf.Addr) {
+			continue
+		}
+
+		handler := http.Handler(self.Engine)
+		if listener.Handler != EngineHandler {
+			var ok bool
+			if handler, ok = self.handlers[listener.Handler]; !ok {
+				return nil, fmt.Errorf("监听 %s 的 handler %q 未通过 SetHandler 注册", listener.Name, listener.Handler)
+			}
+		}
+		listeners = append(listeners, Listener{Name: listener.Name, Network: listener.Network, Addr: listener.Addr, Handler: handler})
+	}
+
+	return listeners, nil
+}
+
+// listen 先同步监听所有地址, 这样端口被占用等错误可以直接返回给服务管理器
+func (self *IHttp) listen() ([]net.Listener, error) {
+	if len(self.listeners) == 0 {
+		return nil, fmt.Errorf("服务 %s 没有配置任何监听地址", self.name)
+	}
+
+	options := self.serverOptions()
+	lns := make([]net.Listener, 0, len(self.listeners))
+	for _, listener := range self.listeners {
+		ln, err := listen(listener, options.TcpKeepAlive)
+		if err != nil {
+			for _, opened := range lns {
+				_ = opened.Close()
+			}
+			return nil, err
+		}
+		lns = append(lns, ln)
+	}
+	if gzutil.ServerAddr == "" && lns[0].Addr().Network() != "unix" {
+		gzutil.ServerAddr = lns[0].Addr().String()
+	}
+
+	return lns, nil
+}
+
+// listen 优先使用平滑重启或 systemd socket activation 继承的监听, 见 gzgrace.Listen
+func listen(listener Listener, keepAlive time.Duration) (net.Listener, error) {
+	if listener.Listener != nil {
+		return listener.Listener, nil
+	}
+
+	network := gzutil.Ternary(listener.Network == "", "tcp", listener.Network)
+	return gzgrace.Listen(listener.Name, network, listener.Addr, func() (net.Listener, error) {
+		if network == "unix" {
+			// 上次异常退出时残留的 socket 文件会导致监听失败
+			if info, err := os.Stat(listener.Addr); err == nil && info.Mode()&os.ModeSocket != 0 {
+				_ = os.Remove(listener.Addr)
+			}
+		}
+		lc := net.ListenConfig{KeepAlive: keepAlive}
+
+		return lc.Listen(context.Background(), network, listener.Addr)
+	})
+}
+
+// begin 标记服务已经启动, 在此之前已经调用了 Stop 时返回 false
+func (self *IHttp) begin() bool {
+	self.lazyInit()
+	self.started.Store(true)
+	select {
+	case <-self.stop:
+		close(self.done)
+		return false
+	default:
+		return true
+	}
+}
+
+// startFailed 启动失败时输出错误, 并结束 Stop 的等待
+func (self *IHttp) startFailed(err error) error {
+	gzconsole.Echo.Errorf("❌  错误: 服务启动异常 %s\n", err)
+	close(self.done)
+
+	return err
+}
+
 func (self *IHttp) running() error {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer close(self.done)
+	defer self.ready.Store(false)
+
+	// 信号由服务管理器统一处理, 这里只需要监听服务上下文
+	select {
+	case err := <-self.exit:
+		self.closeServers()
+		self.stopCallback.Foreach()
+		return err
+	case <-base.ServiceContext().Done():
+		return self.shutdown()
+	case <-self.stop:
+		return self.shutdown()
+	}
+}
+
+// shutdown 停止服务: 标记为未就绪 -> 等待 PreStopDelay -> 停止接收新请求 -> 通知被接管的连接 -> 等待进行中的请求 -> 执行停止回调
+// 等待的时间从开始停止时计算, 包括 PreStopDelay, 并且不超过 Stop 传入的上下文, 避免服务管理器先超时
+func (self *IHttp) shutdown() error {
+	deadline := time.Now().Add(self.timeout)
+	self.draining.Store(true)
+	self.ready.Store(false)
+	if delay := self.serverOptions().PreStopDelay; delay > 0 {
+		gzconsole.Echo.Infof("ℹ️ 提示: 服务 %s 已标记为未就绪, %s 后停止接收新请求\n", self.name, delay)
+		time.Sleep(delay)
+	}
+
+	parent := context.Background()
+	if stopCtx := self.stopCtx.Load(); stopCtx != nil {
+		parent = *stopCtx
+	}
+	ctx, cancel := context.WithDeadline(parent, deadline)
+	defer cancel()
+
+	// http.Server.Shutdown 会直接关闭已经建立但还没读到请求的连接, 所以先关闭监听并等待 Serve 退出,
+	// 再等这些连接开始处理, 避免平滑重启时新进程已经接管监听, 当前进程却丢掉了请求
+	for _, ln := range self.lns {
+		_ = ln.Close()
+	}
+	self.serving.Wait()
+	self.waitPendingConns(ctx)
 
+	for _, fn := range self.onShutdown {
+		gzutil.SafeGo(fn)
+	}
+	err := self.waitRequests(ctx)
+	// 停止回调中一般会关闭数据库等资源, 需要在请求全部处理完之后执行
+	self.stopCallback.Foreach()
+	if err != nil {
+		gzconsole.Echo.Warnf("⚠️  警告: 服务停机失败, 仍有 %d 个请求未处理完: %s\n", self.inFlight.Load(), err)
+
+		return err
+	}
+
+	gzconsole.Echo.Infof("✅  提示: 服务 %s 已成功关闭\n", self.name)
+	return nil
+}
+
+// waitRequests 等待进行中的请求处理完成, 每秒输出一次剩余的请求数
+func (self *IHttp) waitRequests(ctx context.Context) error {
+	done := make(chan error, 1)
+	gzutil.SafeGo(func() {
+		done <- self.shutdownServers(ctx)
+	})
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
 	for {
 		select {
-		case _, _ = <-self.exit:
-			self.stopCallback.Foreach()
-			return nil
-		case <-quit:
-			self.stopCallback.Foreach()
-			ctx, cancel := context.WithTimeout(context.Background(), time.Second*self.timeout)
-			defer cancel()
-			if err := self.srv.Shutdown(ctx); err != nil {
-				gzconsole.Echo.Warnf("⚠️  警告: 服务停机失败: %s\n", err)
-
-				return err
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			if n := self.inFlight.Load(); n > 0 {
+				gzconsole.Echo.Infof("ℹ️ 提示: 服务 %s 正在等待 %d 个请求处理完成\n", self.name, n)
 			}
+		}
+	}
+}
+
+func (self *IHttp) shutdownServers(ctx context.Context) error {
+	errs := make([]error, len(self.servers))
+	var wg sync.WaitGroup
+	for i, srv := range self.servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = srv.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// countRequests 统计正在处理的请求数
+func (self *IHttp) countRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		self.inFlight.Add(1)
+		defer self.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// trackConn 记录还没有读到请求的连接, 用于停止时等待
+func (self *IHttp) trackConn(conn net.Conn, state http.ConnState) {
+	if state == http.StateNew {
+		self.pending.Store(conn, struct{}{})
+		return
+	}
+	self.pending.Delete(conn)
+}
+
+// waitPendingConns 等待已经建立的连接读到请求, 客户端建立连接后一直不发送请求时最多等待 pendingConnWait
+func (self *IHttp) waitPendingConns(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(pendingConnWait)
+	for time.Now().Before(deadline) {
+		empty := true
+		self.pending.Range(func(_, _ any) bool {
+			empty = false
+			return false
+		})
+		if empty {
+			return
+		}
 
-			gzconsole.Echo.Infof("✅  提示: 服务 %s 已成功关闭\n", self.name)
-			return nil
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 	}
 }
+
+// closeServers 某个地址异常退出时, 关闭其他地址, 避免服务只有部分端口可用
+func (self *IHttp) closeServers() {
+	for _, srv := range self.servers {
+		_ = srv.Close()
+	}
+}
+
+// serverOptions 返回 SetOptions 设置的参数, 未设置时读取 app.server 配置
+func (self *IHttp) serverOptions() ServerOptions {
+	if self.options != nil {
+		return *self.options
+	}
+
+	conf := base.GetConfig().App.Server
+	second := func(value, defaultValue int) time.Duration {
+		return time.Duration(gzutil.Ternary(value == 0, defaultValue, value)) * time.Second
+	}
+
+	return ServerOptions{
+		ReadTimeout:       second(conf.ReadTimeout, 0),
+		ReadHeaderTimeout: second(conf.ReadHeaderTimeout, 10),
+		WriteTimeout:      second(conf.WriteTimeout, 0),
+		IdleTimeout:       second(conf.IdleTimeout, 120),
+		MaxHeaderBytes:    gzutil.Ternary(conf.MaxHeaderBytes == 0, http.DefaultMaxHeaderBytes, conf.MaxHeaderBytes),
+		DisableKeepAlive:  conf.DisableKeepAlive,
+		TcpKeepAlive:      second(conf.TcpKeepAlive, 15),
+		H2c:               conf.H2c,
+		PreStopDelay:      second(conf.PreStopDelay, 0),
+	}
+}
+
+func (self *IHttp) displayAddr(listener Listener, ln net.Listener) string {
+	if ln.Addr().Network() == "unix" {
+		return "unix://" + ln.Addr().String()
+	}
+	if listener.Listener == nil && listener.Addr == self.listenAddr {
+		return gzutil.GetServerAddr()
+	}
+
+	return fmt.Sprintf("%s://%s", gzutil.Ternary(self.tls, "https", "http"), ln.Addr().String())
+}
+
+// networkOf 地址中包含 / 时视为 unix socket 文件路径
+func networkOf(addr string) string {
+	if strings.Contains(addr, "/") {
+		return "unix"
+	}
+
+	return "tcp"
+}
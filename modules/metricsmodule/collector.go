@@ -0,0 +1,217 @@
+package metricsmodule
+
+import (
+	"database/sql"
+	"sort"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/pkg/gzmiddleware"
+	"gorm.io/gorm"
+)
+
+// dbCollector 每次采集时遍历 base 中已加载的数据库, 输出连接池状态
+type dbCollector struct {
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxIdleTimeClosed *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+func newDbCollector() prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("go_sql_"+name, help, []string{"db_name"}, nil)
+	}
+
+	return &dbCollector{
+		maxOpen:           desc("max_open_connections", "最大连接数"),
+		open:              desc("open_connections", "当前连接数, 包括使用中和空闲的连接"),
+		inUse:             desc("in_use_connections", "使用中的连接数"),
+		idle:              desc("idle_connections", "空闲的连接数"),
+		waitCount:         desc("wait_count_total", "等待连接的总次数"),
+		waitDuration:      desc("wait_duration_seconds_total", "等待连接的总耗时(秒)"),
+		maxIdleClosed:     desc("max_idle_closed_total", "因超过 MaxIdleConns 关闭的连接数"),
+		maxIdleTimeClosed: desc("max_idle_time_closed_total", "因超过 ConnMaxIdleTime 关闭的连接数"),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "因超过 ConnMaxLifetime 关闭的连接数"),
+	}
+}
+
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{c.maxOpen, c.open, c.inUse, c.idle, c.waitCount, c.waitDuration, c.maxIdleClosed, c.maxIdleTimeClosed, c.maxLifetimeClosed} {
+		ch <- desc
+	}
+}
+
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, db := range loadedDbs() {
+		stats := db.Stats()
+		ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse), name)
+		ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle), name)
+		ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount), name)
+		ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed), name)
+		ch <- prometheus.MustNewConstMetric(c.maxIdleTimeClosed, prometheus.CounterValue, float64(stats.MaxIdleTimeClosed), name)
+		ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed), name)
+	}
+}
+
+// loadedDbs 返回已加载的连接池, `default` 只是别名, 与真实名称指向同一个连接池时只保留真实名称
+func loadedDbs() map[string]*sql.DB {
+	var names []string
+	pools := make(map[string]*sql.DB)
+	base.RangeDb(func(name string, gdb *gorm.DB, sdb *sqlx.DB) bool {
+		var db *sql.DB
+		if gdb != nil {
+			db, _ = gdb.DB()
+		} else if sdb != nil {
+			db = sdb.DB
+		}
+		if db != nil {
+			names = append(names, name)
+			pools[name] = db
+		}
+		return true
+	})
+
+	// default 排在最后, 同一个连接池只输出一次
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "default") != (names[j] == "default") {
+			return names[j] == "default"
+		}
+		return names[i] < names[j]
+	})
+	result := make(map[string]*sql.DB, len(names))
+	seen := make(map[*sql.DB]bool, len(names))
+	for _, name := range names {
+		if db := pools[name]; !seen[db] {
+			seen[db] = true
+			result[name] = db
+		}
+	}
+
+	return result
+}
+
+// redisCollector 输出 base.Rdb 的连接池状态
+type redisCollector struct {
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	total      *prometheus.Desc
+	idle       *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+func newRedisCollector() prometheus.Collector {
+	return &redisCollector{
+		hits:       prometheus.NewDesc("redis_pool_hits_total", "从连接池中取到空闲连接的次数", nil, nil),
+		misses:     prometheus.NewDesc("redis_pool_misses_total", "连接池中没有空闲连接的次数", nil, nil),
+		timeouts:   prometheus.NewDesc("redis_pool_timeouts_total", "等待连接超时的次数", nil, nil),
+		total:      prometheus.NewDesc("redis_pool_connections", "当前连接数", nil, nil),
+		idle:       prometheus.NewDesc("redis_pool_idle_connections", "空闲的连接数", nil, nil),
+		staleConns: prometheus.NewDesc("redis_pool_stale_connections_total", "被移除的失效连接数", nil, nil),
+	}
+}
+
+func (c *redisCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{c.hits, c.misses, c.timeouts, c.total, c.idle, c.staleConns} {
+		ch <- desc
+	}
+}
+
+func (c *redisCollector) Collect(ch chan<- prometheus.Metric) {
+	client, ok := base.Rdb.(interface{ PoolStats() *redis.PoolStats })
+	if !ok {
+		return
+	}
+	stats := client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
+
+// cacheCollector 输出 base.Cache 的命中率等统计
+type cacheCollector struct {
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	evictions   *prometheus.Desc
+	expirations *prometheus.Desc
+	items       *prometheus.Desc
+}
+
+func newCacheCollector() prometheus.Collector {
+	return &cacheCollector{
+		hits:        prometheus.NewDesc("gzcache_hits_total", "缓存命中次数", nil, nil),
+		misses:      prometheus.NewDesc("gzcache_misses_total", "缓存未命中次数", nil, nil),
+		evictions:   prometheus.NewDesc("gzcache_evictions_total", "超出容量被淘汰的次数", nil, nil),
+		expirations: prometheus.NewDesc("gzcache_expirations_total", "过期被清理的次数", nil, nil),
+		items:       prometheus.NewDesc("gzcache_items", "当前缓存的项数", nil, nil),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{c.hits, c.misses, c.evictions, c.expirations, c.items} {
+		ch <- desc
+	}
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	if base.Cache == nil {
+		return
+	}
+	stats := base.Cache.Stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(stats.Expirations))
+	ch <- prometheus.MustNewConstMetric(c.items, prometheus.GaugeValue, float64(stats.Len))
+}
+
+// auditCollector 输出审计日志队列的统计, 用于观察队列满时丢弃的记录
+type auditCollector struct {
+	enqueued *prometheus.Desc
+	dropped  *prometheus.Desc
+	written  *prometheus.Desc
+	failed   *prometheus.Desc
+	queued   *prometheus.Desc
+}
+
+func newAuditCollector() prometheus.Collector {
+	return &auditCollector{
+		enqueued: prometheus.NewDesc("gzaudit_enqueued_total", "进入审计队列的记录数", nil, nil),
+		dropped:  prometheus.NewDesc("gzaudit_dropped_total", "审计队列满时丢弃的记录数", nil, nil),
+		written:  prometheus.NewDesc("gzaudit_written_total", "成功写入的审计记录数(按输出累计)", nil, nil),
+		failed:   prometheus.NewDesc("gzaudit_failed_total", "写入失败的审计记录数(按输出累计)", nil, nil),
+		queued:   prometheus.NewDesc("gzaudit_queued", "审计队列中等待写入的记录数", nil, nil),
+	}
+}
+
+func (c *auditCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{c.enqueued, c.dropped, c.written, c.failed, c.queued} {
+		ch <- desc
+	}
+}
+
+func (c *auditCollector) Collect(ch chan<- prometheus.Metric) {
+	if !base.GetConfig().Audit.Enable {
+		return
+	}
+	stats := gzmiddleware.GetAuditStats()
+	ch <- prometheus.MustNewConstMetric(c.enqueued, prometheus.CounterValue, float64(stats.Enqueued))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.written, prometheus.CounterValue, float64(stats.Written))
+	ch <- prometheus.MustNewConstMetric(c.failed, prometheus.CounterValue, float64(stats.Failed))
+	ch <- prometheus.MustNewConstMetric(c.queued, prometheus.GaugeValue, float64(stats.Queued))
+}
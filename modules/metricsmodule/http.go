@@ -0,0 +1,73 @@
+package metricsmodule
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/w01fb0ss/gin-starter/base"
+)
+
+type httpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+var (
+	httpOnce sync.Once
+	httpStat *httpMetrics
+)
+
+// getHttpMetrics 第一次使用时才创建, 此时配置已经加载, 可以读取 metrics.buckets
+func getHttpMetrics() *httpMetrics {
+	httpOnce.Do(func() {
+		buckets := base.GetConfig().Metrics.Buckets
+		if len(buckets) == 0 {
+			buckets = prometheus.DefBuckets
+		}
+
+		labels := []string{"method", "route", "status"}
+		httpStat = &httpMetrics{
+			requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: "http_requests_total",
+				Help: "HTTP 请求总数",
+			}, labels),
+			duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Name:    "http_request_duration_seconds",
+				Help:    "HTTP 请求耗时(秒)",
+				Buckets: buckets,
+			}, labels),
+			inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+				Name: "http_requests_in_flight",
+				Help: "正在处理的 HTTP 请求数",
+			}),
+		}
+		Registry.MustRegister(httpStat.requests, httpStat.duration, httpStat.inFlight)
+	})
+
+	return httpStat
+}
+
+// Middleware 统计请求数和耗时, route 使用路由模板(如 /user/:id), 未匹配到路由的请求统一记为 unmatched, 避免标签数量失控
+func Middleware() gin.HandlerFunc {
+	metrics := getHttpMetrics()
+
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		metrics.inFlight.Inc()
+		defer metrics.inFlight.Dec()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+		metrics.requests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		metrics.duration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
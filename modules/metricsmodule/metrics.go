@@ -0,0 +1,105 @@
+package metricsmodule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzgrace"
+)
+
+// Registry 本模块使用的指标注册表, 业务自定义的指标也可以注册到这里
+//
+//	metricsmodule.Registry.MustRegister(orderCounter)
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	gzconsole.Register(metricsCmd)
+	base.RegisterConfigValidator(validateConfig)
+	base.SetConfigDefault("Metrics.Path", "/metrics")
+
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		newDbCollector(),
+		newRedisCollector(),
+		newCacheCollector(),
+		newAuditCollector(),
+	)
+}
+
+// validateConfig 校验 `metrics` 配置
+func validateConfig(conf *base.BaseConfig, issues *base.ConfigIssues) {
+	if conf.Metrics.Addr != "" {
+		if _, _, err := net.SplitHostPort(conf.Metrics.Addr); err != nil {
+			issues.AddError("metrics.addr", "%q 不是有效的监听地址, 示例: :9100", conf.Metrics.Addr)
+		}
+	}
+	if conf.Metrics.Path != "" && !strings.HasPrefix(conf.Metrics.Path, "/") {
+		issues.AddError("metrics.path", "必须以 / 开头")
+	}
+	for i := 1; i < len(conf.Metrics.Buckets); i++ {
+		if conf.Metrics.Buckets[i] <= conf.Metrics.Buckets[i-1] {
+			issues.AddError("metrics.buckets", "必须按从小到大的顺序排列")
+			break
+		}
+	}
+}
+
+var metricsCmd = &cobra.Command{
+	Use:    "metrics",
+	Short:  "Init Metrics",
+	Long:   `加载 Prometheus 指标模块, 配置 metrics.addr 时单独监听端口, 否则需要将 metricsmodule.Handler() 挂载到路由上`,
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr := viper.GetString("Metrics.Addr")
+		if addr == "" {
+			gzconsole.Echo.Infof("✅  提示: [Metrics] 模块加载成功, 请将 `metricsmodule.Handler()` 挂载到路由上\n")
+			return nil
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle(viper.GetString("Metrics.Path"), promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+		server := &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		listener, err := gzgrace.Listen(cmd.Name(), "tcp", addr, func() (net.Listener, error) {
+			return net.Listen("tcp", addr)
+		})
+		if err != nil {
+			return fmt.Errorf("指标服务监听 %s 失败: %s", addr, err)
+		}
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				gzconsole.Echo.Errorf("❌  错误: 指标服务异常退出: %s\n", err)
+			}
+		}()
+		gzconsole.RegisterStop(cmd.Name(), func(ctx context.Context) error {
+			return server.Shutdown(ctx)
+		})
+		gzconsole.Echo.Infof("✅  提示: [Metrics] 模块加载成功, 地址为: http://%s%s\n", addr, viper.GetString("Metrics.Path"))
+
+		return nil
+	},
+}
+
+// Handler 输出所有指标, 挂载到业务路由时建议放在 AdminAuth 之后
+//
+//	router.GET("/metrics", gzmiddleware.AdminAuth(), metricsmodule.Handler())
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
@@ -10,10 +10,23 @@ import (
 	"github.com/w01fb0ss/gin-starter/gzconsole"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
 )
 
 func init() {
-	gzconsole.Register(20, mongoCmd)
+	gzconsole.Register(mongoCmd)
+	base.RegisterConfigValidator(validateConfig)
+}
+
+// validateConfig 校验 `mongo` 配置
+func validateConfig(conf *base.BaseConfig, issues *base.ConfigIssues) {
+	if conf.Mongo.URL == "" {
+		issues.AddError("mongo.Url", "已加载 MongoDB 模块, 但未配置连接地址")
+		return
+	}
+	if _, err := connstring.ParseAndValidate(conf.Mongo.URL); err != nil {
+		issues.AddError("mongo.Url", "无法解析: %s", err)
+	}
 }
 
 var mongoCmd = &cobra.Command{
@@ -43,6 +56,12 @@ func initClient(url string) error {
 	}
 
 	base.Mdb = client
+	base.RegisterHealthCheck("mongoDB", func(ctx context.Context) error {
+		return client.Ping(ctx, nil)
+	})
+	gzconsole.RegisterStop("mongoDB", func(ctx context.Context) error {
+		return client.Disconnect(ctx)
+	})
 	gzconsole.Echo.Info("✅ 提示: [Mongo] 模块加载成功, 你可以使用 `base.Mdb` 进行数据操作\n")
 	return nil
 }
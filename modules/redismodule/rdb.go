@@ -3,6 +3,7 @@ package redismodule
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -11,10 +12,26 @@ import (
 	"github.com/spf13/viper"
 	"github.com/w01fb0ss/gin-starter/base"
 	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gztrace"
 )
 
 func init() {
-	gzconsole.Register(9, redisCmd)
+	gzconsole.Register(redisCmd)
+	base.RegisterConfigValidator(validateConfig)
+	base.SetConfigDefault("Redis.IsCluster", false)
+	base.SetConfigDefault("Redis.Db", 0)
+}
+
+// validateConfig 校验 `redis` 配置
+func validateConfig(conf *base.BaseConfig, issues *base.ConfigIssues) {
+	if conf.Redis.Addr == "" {
+		issues.AddError("redis.addr", "已加载 Redis 模块, 但未配置地址")
+	}
+	if conf.Redis.IsCluster && conf.Redis.Db != 0 {
+		issues.AddWarn("redis.db", "集群模式只支持 db 0, 当前配置 %d 不会生效", conf.Redis.Db)
+	} else if conf.Redis.Db < 0 || conf.Redis.Db > 15 {
+		issues.AddError("redis.db", "取值范围为 0-15, 当前为 %d", conf.Redis.Db)
+	}
 }
 
 var redisCmd = &cobra.Command{
@@ -28,8 +45,6 @@ var redisCmd = &cobra.Command{
 			return fmt.Errorf("你正在加载Redis模块，但是你未配置Redis.Addr，请先添加配置")
 		}
 
-		viper.SetDefault("Redis.IsCluster", false)
-		viper.SetDefault("Redis.Db", 0)
 		conn, err := initRedis(
 			addr,
 			viper.GetString("Redis.Password"),
@@ -37,7 +52,18 @@ var redisCmd = &cobra.Command{
 			viper.GetBool("Redis.IsCluster"),
 		)
 		if err == nil {
+			if hooker, ok := conn.(interface{ AddHook(redis.Hook) }); ok && viper.GetBool("Trace.Enable") {
+				hooker.AddHook(gztrace.RedisHook())
+			}
 			base.Rdb = conn
+			base.RegisterHealthCheck(cmd.Name(), func(ctx context.Context) error {
+				return conn.Ping(ctx).Err()
+			})
+			if closer, ok := conn.(io.Closer); ok {
+				gzconsole.RegisterStop(cmd.Name(), func(ctx context.Context) error {
+					return closer.Close()
+				})
+			}
 			gzconsole.Echo.Infof("✅  提示: [Redis] 模块加载成功, 你可以使用 `base.Rdb` 进行数据操作\n")
 		}
 
@@ -0,0 +1,141 @@
+package tracemodule
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const (
+	ExporterOtlp   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+func init() {
+	gzconsole.Register(traceCmd)
+	base.RegisterConfigValidator(validateConfig)
+	base.SetConfigDefault("Trace.Exporter", ExporterOtlp)
+	base.SetConfigDefault("Trace.SampleRatio", 1.0)
+}
+
+// validateConfig 校验 `trace` 配置
+func validateConfig(conf *base.BaseConfig, issues *base.ConfigIssues) {
+	trace := conf.Trace
+	if !trace.Enable {
+		return
+	}
+	switch trace.Exporter {
+	case "", ExporterOtlp, ExporterStdout:
+	case ExporterFile:
+		if trace.File == "" {
+			issues.AddError("trace.file", "exporter 为 file 时必须配置文件路径")
+		}
+	default:
+		issues.AddError("trace.exporter", "不支持的导出方式 %q, 可选值: otlp、stdout、file", trace.Exporter)
+	}
+	if trace.SampleRatio < 0 || trace.SampleRatio > 1 {
+		issues.AddError("trace.sampleRatio", "取值范围为 0-1, 当前为 %v", trace.SampleRatio)
+	}
+}
+
+var traceCmd = &cobra.Command{
+	Use:    "trace",
+	Short:  "Init Trace",
+	Long:   `加载链路追踪模块, 基于 OpenTelemetry, 支持 W3C traceparent 透传`,
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !viper.GetBool("Trace.Enable") {
+			return nil
+		}
+
+		exporter, closeFunc, err := newExporter(viper.GetString("Trace.Exporter"))
+		if err != nil {
+			return err
+		}
+
+		serviceName := gzutil.Ternary(viper.GetString("Trace.ServiceName") != "", viper.GetString("Trace.ServiceName"), viper.GetString("App.Name"))
+		res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+			semconv.DeploymentEnvironmentName(viper.GetString("App.Env")),
+		))
+		if err != nil {
+			return fmt.Errorf("创建链路追踪资源失败: %s", err)
+		}
+
+		provider := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+			sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(viper.GetFloat64("Trace.SampleRatio")))),
+		)
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+		// 先导出剩余的 span, 再关闭文件
+		gzconsole.RegisterStop(cmd.Name(), func(ctx context.Context) error {
+			err := provider.Shutdown(ctx)
+			if closeFunc != nil {
+				_ = closeFunc()
+			}
+			return err
+		})
+		gzconsole.Echo.Infof("✅  提示: [Trace] 模块加载成功, 服务名 %s, 导出方式 %s\n", serviceName, viper.GetString("Trace.Exporter"))
+
+		return nil
+	},
+}
+
+// newExporter 创建 span 导出器, 返回的 closeFunc 用于关闭打开的文件
+func newExporter(name string) (sdktrace.SpanExporter, func() error, error) {
+	switch name {
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, nil, err
+	case ExporterFile:
+		file := viper.GetString("Trace.File")
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return nil, nil, fmt.Errorf("创建链路追踪文件目录失败: %s", err)
+		}
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("打开链路追踪文件失败: %s", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		return exporter, f.Close, err
+	case ExporterOtlp:
+		var opts []otlptracehttp.Option
+		if endpoint := viper.GetString("Trace.Endpoint"); strings.Contains(endpoint, "://") {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		} else if endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if viper.GetBool("Trace.Insecure") {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if headers := viper.GetStringMapString("Trace.Headers"); len(headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(headers))
+		}
+		exporter, err := otlptracehttp.New(context.Background(), opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("创建 OTLP 导出器失败: %s", err)
+		}
+		return exporter, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("不支持的链路追踪导出方式: %s", name)
+	}
+}
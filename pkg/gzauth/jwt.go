@@ -3,26 +3,38 @@ package gzauth
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/spf13/viper"
+	"github.com/w01fb0ss/gin-starter/base"
 	"github.com/w01fb0ss/gin-starter/gzconsole"
 	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
 )
 
-var secretKey []byte
+var secretKey atomic.Pointer[[]byte]
+
+func init() {
+	// 配置热重载后重新读取密钥
+	base.OnConfigChange("jwt", func(_, _ *base.BaseConfig) {
+		secretKey.Store(nil)
+	})
+}
 
 func getSecretKey() []byte {
-	if len(secretKey) == 0 {
-		secretKey = loadSecretKey()
+	if key := secretKey.Load(); key != nil {
+		return *key
 	}
 
-	return secretKey
+	key := loadSecretKey()
+	secretKey.Store(&key)
+
+	return key
 }
 
 func loadSecretKey() []byte {
-	key := viper.GetString("Jwt.SecretKey")
+	key := base.GetConfig().Jwt.SecretKey
 	if key != "" {
 		return []byte(key)
 	}
@@ -40,7 +52,7 @@ func GenerateJwtToken(claimsMap jwt.MapClaims) (string, error) {
 	claimsMap["iat"] = time.Now().Unix()
 	claimsMap["nbf"] = time.Now().Unix()
 	if _, ok := claimsMap["exp"]; !ok {
-		expire := viper.GetInt64("Jwt.Expire")
+		expire := base.GetConfig().Jwt.Expire
 		if expire > 0 {
 			claimsMap["exp"] = time.Now().Add(time.Duration(expire) * time.Second).Unix()
 		} else {
@@ -73,9 +85,32 @@ func ParseJwtToken(tokenString string) (map[string]interface{}, error) {
 	return nil, fmt.Errorf("无效的 Token")
 }
 
+// claimsKey Token claims 在 context 中的键
+type claimsKey struct{}
+
+// ContextWithClaims 将 Token 的 claims 保存到 context 中, 这样传递 Request.Context() 的下层代码也能取到当前用户
+func ContextWithClaims(ctx context.Context, claims map[string]interface{}) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// GetClaims 获取 context 中的 Token claims, 同时支持 *gin.Context 和 Request.Context()
+func GetClaims(ctx context.Context) map[string]interface{} {
+	if ginCtx, ok := ctx.(*gin.Context); ok && ginCtx.Request != nil {
+		if claims, ok := ginCtx.Request.Context().Value(claimsKey{}).(map[string]interface{}); ok {
+			return claims
+		}
+	}
+	if claims, ok := ctx.Value(claimsKey{}).(map[string]interface{}); ok {
+		return claims
+	}
+	claims, _ := ctx.Value("claims").(map[string]interface{})
+
+	return claims
+}
+
 func GetTokenValue[T gzutil.MapSupportedTypes](ctx context.Context, key string) T {
-	claimsMap, ok := ctx.Value("claims").(map[string]interface{})
-	if !ok {
+	claimsMap := GetClaims(ctx)
+	if claimsMap == nil {
 		var zero T
 		return zero
 	}
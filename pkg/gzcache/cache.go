@@ -39,6 +39,20 @@ type CacheNode struct {
 	cleanerStop    chan struct{}
 	cleanerRunning atomic.Bool
 	cleanInterval  time.Duration
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+// Stats 缓存的统计数据, 计数从创建缓存开始累计
+type Stats struct {
+	Hits        uint64 // 命中次数
+	Misses      uint64 // 未命中次数(包括已过期)
+	Evictions   uint64 // 超出容量被淘汰的次数
+	Expirations uint64 // 过期被清理的次数
+	Len         int    // 当前的项数
 }
 
 // defaultShardCount 是默认分片数量（必须为 2 的幂）
@@ -105,6 +119,7 @@ func (c *CacheNode) Set(key string, value any, ttl time.Duration) {
 	if node, exists := s.items[key]; exists {
 		node.value = value
 		node.expiresAt = expiresAt
+		node.ttl = ttl     // 续期使用新的 TTL
 		s.moveToHead(node) // 更新了，移到头部
 	} else {
 		// 新节点添加到 map 和链表头部
@@ -133,6 +148,7 @@ func (c *CacheNode) Get(key string) (any, bool) {
 
 	node, exists := s.items[key]
 	if !exists {
+		c.misses.Add(1)
 		return nil, false
 	}
 
@@ -141,11 +157,16 @@ func (c *CacheNode) Get(key string) (any, bool) {
 		s.removeNode(node)
 		delete(s.items, key)
 		s.count.Add(-1)
+		c.expirations.Add(1)
+		c.misses.Add(1)
 		return nil, false
 	}
+	c.hits.Add(1)
 
-	// 未过期，自动续期并将其移动到链表头部 (标记为最近使用)
-	node.expiresAt = time.Now().Add(node.ttl)
+	// 未过期，自动续期并将其移动到链表头部 (标记为最近使用)，永不过期的项不需要续期
+	if node.ttl > 0 {
+		node.expiresAt = time.Now().Add(node.ttl)
+	}
 	s.moveToHead(node)
 	return node.value, true
 }
@@ -175,6 +196,17 @@ func (c *CacheNode) Len() int {
 	return int(total)
 }
 
+// Stats 返回缓存的统计数据
+func (c *CacheNode) Stats() Stats {
+	return Stats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+		Len:         c.Len(),
+	}
+}
+
 // SetOnEvict 设置删除/淘汰时的回调函数
 func (c *CacheNode) SetOnEvict(cb func(string, any)) {
 	c.onEvict = cb
@@ -258,12 +290,15 @@ func (s *shard) moveToHead(node *listNode) {
 
 // removeLRU 移除链表末尾的节点，表示最近最少使用
 func (s *shard) removeLRU() {
-	if s.tail == nil {
+	node := s.tail
+	if node == nil {
 		return
 	}
-	s.removeNode(s.tail)
-	delete(s.items, s.tail.key)
+	// removeNode 会修改 s.tail, 需要先保存被淘汰的节点
+	s.removeNode(node)
+	delete(s.items, node.key)
 	s.count.Add(-1)
+	s.parent.evictions.Add(1)
 }
 
 // 启动后台协程定期清理所有过期项
@@ -297,6 +332,7 @@ func (c *CacheNode) cleanExpired() {
 				s.removeNode(node)
 				delete(s.items, key)
 				s.count.Add(-1)
+				c.expirations.Add(1)
 			}
 		}
 		s.mu.Unlock()
@@ -0,0 +1,67 @@
+package gzcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetUpdatesTtl(t *testing.T) {
+	c := New(0, 1, 0)
+
+	// 更新时使用新的 TTL, 续期也使用新的 TTL
+	c.Set("a", 1, 50*time.Millisecond)
+	c.Set("a", 2, time.Hour)
+	time.Sleep(100 * time.Millisecond)
+	if v, ok := c.Get("a"); !ok || v != 2 {
+		t.Fatalf("Get(a) = %v, %v", v, ok)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("续期后不应过期")
+	}
+
+	// 从有 TTL 改为永不过期
+	c.Set("b", 1, 50*time.Millisecond)
+	c.Set("b", 2, 0)
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := c.Get("b"); !ok {
+		t.Fatal("ttl 为 0 时不应过期")
+	}
+}
+
+func TestZeroTtlNeverExpires(t *testing.T) {
+	c := New(0, 1, 10*time.Millisecond)
+	defer c.Close()
+
+	c.Set("a", 1, 0)
+	for i := 0; i < 3; i++ {
+		if _, ok := c.Get("a"); !ok {
+			t.Fatalf("第 %d 次 Get 时已过期", i+1)
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+	if stats := c.Stats(); stats.Expirations != 0 || stats.Hits != 3 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	c := New(2, 1, 0)
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Get("a")
+	c.Set("c", 3, 0)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("b 是最近最少使用的, 应被淘汰")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Fatalf("%s 不应被淘汰", key)
+		}
+	}
+	if c.Len() != 2 || c.Stats().Evictions != 1 {
+		t.Fatalf("Len = %d, stats = %+v", c.Len(), c.Stats())
+	}
+}
@@ -0,0 +1,60 @@
+package gzgeo
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+// Provider IP 归属地查询, 可以接入 ip2region、MaxMind 等离线库或者第三方接口
+type Provider interface {
+	Lookup(ip string) (string, error)
+}
+
+// ProviderFunc 将函数转换为 Provider
+type ProviderFunc func(ip string) (string, error)
+
+func (f ProviderFunc) Lookup(ip string) (string, error) {
+	return f(ip)
+}
+
+type providerHolder struct {
+	Provider
+}
+
+var provider atomic.Pointer[providerHolder]
+
+// SetProvider 设置全局的归属地查询, 传入 nil 时关闭查询
+func SetProvider(p Provider) {
+	if p == nil {
+		provider.Store(nil)
+		return
+	}
+	provider.Store(&providerHolder{Provider: p})
+}
+
+// HasProvider 是否已设置归属地查询
+func HasProvider() bool {
+	return provider.Load() != nil
+}
+
+// Lookup 查询 IP 归属地, 内网 IP 返回 `内网IP`, 未设置查询或查询失败时返回空字符串
+func Lookup(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if parsed.IsLoopback() || parsed.IsPrivate() || parsed.IsLinkLocalUnicast() {
+		return "内网IP"
+	}
+
+	holder := provider.Load()
+	if holder == nil {
+		return ""
+	}
+	address, err := holder.Lookup(ip)
+	if err != nil {
+		return ""
+	}
+
+	return address
+}
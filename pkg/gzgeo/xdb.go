@@ -0,0 +1,92 @@
+package gzgeo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+const (
+	xdbHeaderLength      = 256
+	xdbVectorIndexRows   = 256
+	xdbVectorIndexCols   = 256
+	xdbVectorIndexSize   = 8
+	xdbSegmentIndexSize  = 14
+	xdbVectorIndexLength = xdbVectorIndexRows * xdbVectorIndexCols * xdbVectorIndexSize
+)
+
+// XdbProvider 基于 ip2region 的 xdb 离线库(IPv4 版本)查询归属地, 整个文件加载到内存中, 可以并发查询
+// 数据文件可以从 https://github.com/lionsoul2014/ip2region 获取
+type XdbProvider struct {
+	content []byte
+}
+
+// NewXdbProvider 加载 xdb 文件
+func NewXdbProvider(file string) (*XdbProvider, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取 IP 库失败: %s", err)
+	}
+	if len(content) < xdbHeaderLength+xdbVectorIndexLength {
+		return nil, fmt.Errorf("IP 库 %s 不是有效的 xdb 文件", file)
+	}
+
+	return &XdbProvider{content: content}, nil
+}
+
+// Lookup 返回格式化后的归属地, 如 `中国 广东省 深圳市 电信`
+func (p *XdbProvider) Lookup(ip string) (string, error) {
+	ipv4 := net.ParseIP(ip).To4()
+	if ipv4 == nil {
+		return "", fmt.Errorf("xdb 只支持 IPv4: %s", ip)
+	}
+	region, err := p.search(binary.BigEndian.Uint32(ipv4))
+	if err != nil {
+		return "", err
+	}
+
+	// 原始数据为 国家|区域|省份|城市|ISP, 未知的部分为 0
+	var parts []string
+	for _, part := range strings.Split(region, "|") {
+		if part != "" && part != "0" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func (p *XdbProvider) search(ip uint32) (string, error) {
+	// 通过前两个字节定位向量索引, 得到该区间的段索引范围
+	il0, il1 := (ip>>24)&0xFF, (ip>>16)&0xFF
+	idx := xdbHeaderLength + int(il0)*xdbVectorIndexCols*xdbVectorIndexSize + int(il1)*xdbVectorIndexSize
+	sPtr := int(binary.LittleEndian.Uint32(p.content[idx:]))
+	ePtr := int(binary.LittleEndian.Uint32(p.content[idx+4:]))
+	if sPtr == 0 || ePtr == 0 || ePtr+xdbSegmentIndexSize > len(p.content) {
+		return "", fmt.Errorf("未找到 IP 对应的记录")
+	}
+
+	// 二分查找段索引: startIp(4) endIp(4) dataLen(2) dataPtr(4)
+	low, high := 0, (ePtr-sPtr)/xdbSegmentIndexSize
+	for low <= high {
+		mid := (low + high) / 2
+		offset := sPtr + mid*xdbSegmentIndexSize
+		segment := p.content[offset : offset+xdbSegmentIndexSize]
+		if ip < binary.LittleEndian.Uint32(segment) {
+			high = mid - 1
+		} else if ip > binary.LittleEndian.Uint32(segment[4:]) {
+			low = mid + 1
+		} else {
+			dataLen := int(binary.LittleEndian.Uint16(segment[8:]))
+			dataPtr := int(binary.LittleEndian.Uint32(segment[10:]))
+			if dataPtr+dataLen > len(p.content) {
+				return "", fmt.Errorf("IP 库数据损坏")
+			}
+			return string(p.content[dataPtr : dataPtr+dataLen]), nil
+		}
+	}
+
+	return "", fmt.Errorf("未找到 IP 对应的记录")
+}
@@ -0,0 +1,143 @@
+package gzgeo
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type xdbRange struct {
+	start, end string
+	region     string
+}
+
+func ipToUint32(ip string) uint32 {
+	return binary.BigEndian.Uint32(net.ParseIP(ip).To4())
+}
+
+// buildXdb 按照 ip2region 的格式生成 xdb 文件: 头部、向量索引、数据、段索引, 与官方生成器一样按 /16 切分段
+func buildXdb(ranges []xdbRange) []byte {
+	content := make([]byte, xdbHeaderLength+xdbVectorIndexLength)
+	dataPtrs := make([]int, len(ranges))
+	for i, r := range ranges {
+		dataPtrs[i] = len(content)
+		content = append(content, r.region...)
+	}
+
+	segment := make([]byte, xdbSegmentIndexSize)
+	for i, r := range ranges {
+		start, end := ipToUint32(r.start), ipToUint32(r.end)
+		for {
+			blockEnd := start | 0xFFFF
+			if blockEnd > end {
+				blockEnd = end
+			}
+			binary.LittleEndian.PutUint32(segment, start)
+			binary.LittleEndian.PutUint32(segment[4:], blockEnd)
+			binary.LittleEndian.PutUint16(segment[8:], uint16(len(r.region)))
+			binary.LittleEndian.PutUint32(segment[10:], uint32(dataPtrs[i]))
+
+			ptr := uint32(len(content))
+			idx := xdbHeaderLength + int(start>>16)*xdbVectorIndexSize
+			if binary.LittleEndian.Uint32(content[idx:]) == 0 {
+				binary.LittleEndian.PutUint32(content[idx:], ptr)
+			}
+			binary.LittleEndian.PutUint32(content[idx+4:], ptr)
+			content = append(content, segment...)
+
+			if blockEnd == end {
+				break
+			}
+			start = blockEnd + 1
+		}
+	}
+
+	return content
+}
+
+func writeXdb(t *testing.T, content []byte) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "ip.xdb")
+	if err := os.WriteFile(file, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+var testRanges = []xdbRange{
+	{"0.0.0.0", "0.255.255.255", "0|0|0|内网IP|内网IP"},
+	{"1.0.0.0", "1.0.0.255", "中国|0|广东省|深圳市|电信"},
+	{"1.0.1.0", "1.0.1.255", "中国|0|福建省|福州市|电信"},
+	{"2.0.128.0", "2.1.127.255", "美国|0|加利福尼亚|0|0"},
+	{"255.255.255.0", "255.255.255.255", "保留|0|0|0|0"},
+}
+
+func TestXdbProviderLookup(t *testing.T) {
+	p, err := NewXdbProvider(writeXdb(t, buildXdb(testRanges)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		ip     string
+		region string
+		err    bool
+	}{
+		{ip: "0.0.0.0", region: "内网IP 内网IP"},
+		{ip: "1.0.0.0", region: "中国 广东省 深圳市 电信"},
+		{ip: "1.0.0.255", region: "中国 广东省 深圳市 电信"},
+		{ip: "1.0.1.0", region: "中国 福建省 福州市 电信"},
+		{ip: "1.0.1.255", region: "中国 福建省 福州市 电信"},
+		{ip: "2.0.255.255", region: "美国 加利福尼亚"}, // 跨越 /16 的区间
+		{ip: "2.1.0.0", region: "美国 加利福尼亚"},
+		{ip: "255.255.255.255", region: "保留"},
+		{ip: "1.0.2.0", err: true},     // 同一个 /16 中没有记录
+		{ip: "2.0.127.255", err: true}, // 区间前一个地址
+		{ip: "3.0.0.1", err: true},     // 向量索引为空
+		{ip: "::ffff:1.0.0.1", region: "中国 广东省 深圳市 电信"},
+		{ip: "2001:db8::1", err: true},
+		{ip: "invalid", err: true},
+	}
+	for _, c := range cases {
+		region, err := p.Lookup(c.ip)
+		if (err != nil) != c.err || region != c.region {
+			t.Errorf("Lookup(%s) = %q, %v", c.ip, region, err)
+		}
+	}
+}
+
+func TestXdbProviderCorrupt(t *testing.T) {
+	content := buildXdb(testRanges)
+
+	// 文件不完整, 连向量索引都没有
+	if _, err := NewXdbProvider(writeXdb(t, content[:xdbHeaderLength+100])); err == nil {
+		t.Fatal("不完整的文件应返回错误")
+	}
+	if _, err := NewXdbProvider(filepath.Join(t.TempDir(), "missing.xdb")); err == nil {
+		t.Fatal("文件不存在应返回错误")
+	}
+
+	// 段索引被截断, 以及数据指针越界时返回错误, 不能 panic
+	truncated := content[:len(content)-xdbSegmentIndexSize]
+	corrupt := append([]byte(nil), content...)
+	binary.LittleEndian.PutUint32(corrupt[len(corrupt)-xdbSegmentIndexSize+10:], uint32(len(corrupt)))
+	for name, data := range map[string][]byte{"truncated": truncated, "corrupt": corrupt} {
+		p, err := NewXdbProvider(writeXdb(t, data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if region, err := p.Lookup("255.255.255.255"); err == nil {
+			t.Errorf("%s: Lookup = %q, 应返回错误", name, region)
+		} else if name == "corrupt" && !strings.Contains(err.Error(), "损坏") {
+			t.Errorf("%s: %s", name, err)
+		}
+		if region, err := p.Lookup("1.0.0.1"); err != nil || region != "中国 广东省 深圳市 电信" {
+			t.Errorf("%s: 未损坏的记录 Lookup = %q, %v", name, region, err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package gzgrace
+
+import (
+	"net"
+	"os"
+	"sync"
+)
+
+const (
+	// listenFdsStart 继承的文件描述符从 3 开始, 0、1、2 为标准输入输出
+	listenFdsStart = 3
+
+	envListenFds = "GZ_GRACE_FDS"      // 平滑重启时父进程传递的监听数量
+	envReadyFd   = "GZ_GRACE_READY_FD" // 新进程就绪后写入的管道
+)
+
+type inheritedListener struct {
+	name string
+	ln   net.Listener
+	used bool
+}
+
+var (
+	mu          sync.Mutex
+	inheritOnce sync.Once
+	inherited   []*inheritedListener
+	active      []net.Listener
+	readyFile   *os.File
+)
+
+// Listen 优先使用从父进程(平滑重启)或 systemd(socket activation)继承的监听, 没有匹配的监听时调用 listen 新建
+// 返回的监听会被记录, 平滑重启时交给新进程
+//
+//	ln, err := gzgrace.Listen("main", "tcp", ":8080", func() (net.Listener, error) {
+//		return net.Listen("tcp", ":8080")
+//	})
+func Listen(name, network, addr string, listen func() (net.Listener, error)) (net.Listener, error) {
+	inheritOnce.Do(loadInherited)
+	mu.Lock()
+	defer mu.Unlock()
+
+	ln := takeInherited(name, network, addr)
+	if ln == nil {
+		var err error
+		if ln, err = listen(); err != nil {
+			return nil, err
+		}
+	}
+	active = append(active, ln)
+
+	return ln, nil
+}
+
+// Inherited 当前进程是否继承了监听, 即由平滑重启或 systemd socket activation 启动
+func Inherited() bool {
+	inheritOnce.Do(loadInherited)
+	mu.Lock()
+	defer mu.Unlock()
+
+	return len(inherited) > 0
+}
+
+// Ready 通知父进程新进程已就绪, 父进程收到后开始停止, 不是由平滑重启启动时什么都不做
+func Ready() error {
+	inheritOnce.Do(loadInherited)
+	mu.Lock()
+	file := readyFile
+	readyFile = nil
+	mu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+	defer file.Close()
+	_, err := file.Write([]byte{1})
+
+	return err
+}
+
+// takeInherited 先按地址匹配, 再按 systemd 的 FileDescriptorName 匹配, 每个继承的监听只能使用一次
+func takeInherited(name, network, addr string) net.Listener {
+	for _, item := range inherited {
+		if !item.used && sameAddr(item.ln.Addr(), network, addr) {
+			item.used = true
+			return item.ln
+		}
+	}
+	for _, item := range inherited {
+		if !item.used && name != "" && item.name == name {
+			item.used = true
+			return item.ln
+		}
+	}
+
+	return nil
+}
+
+// sameAddr 判断监听地址是否与配置的地址相同, 未指定 IP 时(如 :8080)只匹配监听所有 IP 的地址
+func sameAddr(la net.Addr, network, addr string) bool {
+	switch la := la.(type) {
+	case *net.UnixAddr:
+		return network == "unix" && la.Name == addr
+	case *net.TCPAddr:
+		if network == "unix" {
+			return false
+		}
+		ra, err := net.ResolveTCPAddr(network, addr)
+		if err != nil || ra.Port != la.Port {
+			return false
+		}
+		if ra.IP == nil || ra.IP.IsUnspecified() {
+			return la.IP.IsUnspecified()
+		}
+		return ra.IP.Equal(la.IP)
+	}
+
+	return false
+}
@@ -0,0 +1,207 @@
+//go:build !windows
+
+package gzgrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+var upgrading atomic.Bool
+
+// loadInherited 读取父进程或 systemd 传递的监听, 读取后清除环境变量, 避免再传给子进程
+func loadInherited() {
+	defer func() {
+		for _, key := range []string{envListenFds, envReadyFd, "LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+			_ = os.Unsetenv(key)
+		}
+	}()
+
+	if fd, err := strconv.Atoi(os.Getenv(envReadyFd)); err == nil && fd >= listenFdsStart {
+		syscall.CloseOnExec(fd)
+		readyFile = os.NewFile(uintptr(fd), "gzgrace-ready")
+	}
+	if n, err := strconv.Atoi(os.Getenv(envListenFds)); err == nil && n > 0 {
+		inherited = fileListeners(n, nil)
+		return
+	}
+
+	// systemd socket activation, 见 sd_listen_fds(3)
+	if pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID")); pid != os.Getpid() {
+		return
+	}
+	if n, err := strconv.Atoi(os.Getenv("LISTEN_FDS")); err == nil && n > 0 {
+		inherited = fileListeners(n, strings.Split(os.Getenv("LISTEN_FDNAMES"), ":"))
+	}
+}
+
+// fileListeners 将继承的文件描述符转换为监听, 不是监听 socket 的(如 UDP)会被忽略
+func fileListeners(n int, names []string) []*inheritedListener {
+	var listeners []*inheritedListener
+	for i := 0; i < n; i++ {
+		fd := listenFdsStart + i
+		syscall.CloseOnExec(fd)
+		file := os.NewFile(uintptr(fd), "gzgrace-listener")
+		ln, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			continue
+		}
+
+		item := &inheritedListener{ln: ln}
+		if i < len(names) {
+			item.name = names[i]
+		}
+		listeners = append(listeners, item)
+	}
+
+	return listeners
+}
+
+// Upgrade 使用相同的参数启动新的可执行文件, 并把通过 Listen 创建的监听交给它, 新进程调用 Ready 后返回 nil
+// 返回 nil 后当前进程应停止接收新请求, 处理完进行中的请求后退出; 新进程启动失败、提前退出或 ctx 超时时返回错误,
+// 新进程会被终止, 当前进程继续提供服务
+//
+// 由 systemd 管理时主进程退出会导致整个服务被停止, 应使用 socket activation 配合 systemctl restart
+func Upgrade(ctx context.Context) error {
+	if !upgrading.CompareAndSwap(false, true) {
+		return errors.New("平滑重启正在进行中")
+	}
+	defer upgrading.Store(false)
+
+	fds, listeners, err := listenerFds()
+	defer func() {
+		for _, fd := range fds {
+			_ = syscall.Close(int(fd))
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("获取可执行文件失败: %w", err)
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// 不使用 exec.Cmd 的 ExtraFiles, 它会调用 File.Fd() 把 socket 改为阻塞模式, 而该标志由父子进程共享,
+	// 会导致当前进程关闭监听后仍可能 Accept 到连接并直接关闭
+	files := append([]uintptr{uintptr(syscall.Stdin), uintptr(syscall.Stdout), uintptr(syscall.Stderr)}, fds...)
+	env := append(cleanEnv(os.Environ()),
+		fmt.Sprintf("%s=%d", envListenFds, len(fds)),
+		fmt.Sprintf("%s=%d", envReadyFd, listenFdsStart+len(fds)),
+	)
+	pid, err := syscall.ForkExec(executable, os.Args, &syscall.ProcAttr{
+		Env:   env,
+		Files: append(files, writer.Fd()),
+	})
+	_ = writer.Close()
+	if err != nil {
+		return fmt.Errorf("启动新进程失败: %w", err)
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		state, err := process.Wait()
+		if err == nil {
+			err = errors.New(state.String())
+		}
+		exited <- err
+	}()
+	ready := make(chan error, 1)
+	go func() {
+		// 新进程退出时管道关闭, 读取到 EOF
+		_, err := reader.Read(make([]byte, 1))
+		ready <- err
+	}()
+
+	select {
+	case err = <-ready:
+		if err == nil {
+			// 当前进程关闭监听时不能删除 unix socket 文件, 新进程还在使用
+			for _, ln := range listeners {
+				if ul, ok := ln.(*net.UnixListener); ok {
+					ul.SetUnlinkOnClose(false)
+				}
+			}
+			return nil
+		}
+		err = fmt.Errorf("新进程未就绪: %w", err)
+	case err = <-exited:
+		return fmt.Errorf("新进程已退出: %w", err)
+	case <-ctx.Done():
+		err = fmt.Errorf("等待新进程就绪超时: %w", ctx.Err())
+	}
+	_ = process.Kill()
+
+	return err
+}
+
+// listenerFds 复制所有监听的文件描述符, 已经关闭的监听会被忽略
+func listenerFds() ([]uintptr, []net.Listener, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var fds []uintptr
+	var listeners []net.Listener
+	for _, ln := range active {
+		conn, ok := ln.(syscall.Conn)
+		if !ok {
+			return fds, nil, fmt.Errorf("监听 %s 不支持传递给新进程", ln.Addr())
+		}
+		raw, err := conn.SyscallConn()
+		if err != nil {
+			return fds, nil, err
+		}
+
+		var fd int
+		var dupErr error
+		err = raw.Control(func(sysfd uintptr) {
+			syscall.ForkLock.RLock()
+			defer syscall.ForkLock.RUnlock()
+			if fd, dupErr = syscall.Dup(int(sysfd)); dupErr == nil {
+				syscall.CloseOnExec(fd)
+			}
+		})
+		if errors.Is(err, net.ErrClosed) {
+			continue
+		}
+		if err = errors.Join(err, dupErr); err != nil {
+			return fds, nil, fmt.Errorf("复制监听 %s 失败: %w", ln.Addr(), err)
+		}
+		fds = append(fds, uintptr(fd))
+		listeners = append(listeners, ln)
+	}
+
+	return fds, listeners, nil
+}
+
+func cleanEnv(environ []string) []string {
+	env := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		switch key {
+		case envListenFds, envReadyFd, "LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES":
+			continue
+		}
+		env = append(env, kv)
+	}
+
+	return env
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package gzgrace
+
+import (
+	"context"
+	"errors"
+)
+
+func loadInherited() {}
+
+// Upgrade Windows 不支持传递监听, 总是返回错误
+func Upgrade(ctx context.Context) error {
+	return errors.New("Windows 不支持平滑重启")
+}
@@ -2,6 +2,7 @@ package gzhttp
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/spf13/cast"
+	"github.com/w01fb0ss/gin-starter/pkg/gztrace"
 )
 
 const (
@@ -23,6 +25,7 @@ const (
 
 // RequestConfig 封装请求参数
 type RequestConfig struct {
+	Ctx       context.Context // 传入请求的 context 后会把链路信息传递给下游, 并随 ctx 取消请求
 	Method    string
 	Url       string
 	Headers   map[string]string
@@ -63,7 +66,11 @@ func DoRequest(cfg RequestConfig) ([]byte, int, error) {
 		}
 	}
 
-	req, err := http.NewRequest(cfg.Method, parsedUrl.String(), bodyReader)
+	ctx := cfg.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, parsedUrl.String(), bodyReader)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -90,7 +97,7 @@ func DoRequest(cfg RequestConfig) ([]byte, int, error) {
 
 	client := &http.Client{
 		Timeout:   cfg.Timeout,
-		Transport: transport,
+		Transport: gztrace.Transport(transport),
 	}
 
 	resp, err := client.Do(req)
@@ -0,0 +1,65 @@
+package gzmiddleware
+
+import (
+	"crypto/subtle"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/pkg/gzerror"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+)
+
+// AdminAuth 保护管理接口, 对应配置 `admin`:
+//   - token: 请求需要携带 `X-Admin-Token: <token>` 或 `Authorization: Bearer <token>`
+//   - allowIps: 允许访问的 IP 或 CIDR, 如 10.0.0.0/8
+//
+// 两者都配置时需要同时满足, 都为空时只允许本机访问
+// IP 通过 gzutil.ClientIP 获取, 只有来自 ip.trustedProxies 中可信代理的请求才会读取 X-Forwarded-For 等请求头
+func AdminAuth() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		conf := base.GetConfig().Admin
+		ip := net.ParseIP(gzutil.GetClientRealIP(ctx))
+
+		allowed := true
+		if len(conf.AllowIps) > 0 {
+			allowed = ipAllowed(ip, conf.AllowIps)
+		} else if conf.Token == "" {
+			allowed = ip != nil && ip.IsLoopback()
+		}
+		if allowed && conf.Token != "" {
+			allowed = subtle.ConstantTimeCompare([]byte(adminToken(ctx)), []byte(conf.Token)) == 1
+		}
+
+		if !allowed {
+			base.Fail(ctx, gzerror.NoAuth)
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+func adminToken(ctx *gin.Context) string {
+	if token := ctx.GetHeader("X-Admin-Token"); token != "" {
+		return token
+	}
+
+	return strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+}
+
+func ipAllowed(ip net.IP, allowIps []string) bool {
+	if ip == nil {
+		return false
+	}
+	nets, _ := gzutil.ParseIPNets(allowIps)
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
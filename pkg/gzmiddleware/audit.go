@@ -0,0 +1,373 @@
+package gzmiddleware
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/gzconsole"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
+	"go.uber.org/zap"
+)
+
+// AuditSink 审计日志的输出, Write 会在后台协程中按批调用
+type AuditSink interface {
+	Write(ctx context.Context, records []*RequestLogData) error
+}
+
+// AuditSinkFunc 将函数转换为 AuditSink
+type AuditSinkFunc func(ctx context.Context, records []*RequestLogData) error
+
+func (f AuditSinkFunc) Write(ctx context.Context, records []*RequestLogData) error {
+	return f(ctx, records)
+}
+
+var (
+	auditSinkMu sync.RWMutex
+	auditSinks  = map[string]func() (AuditSink, error){
+		"log":   newLogAuditSink,
+		"gorm":  newGormAuditSink,
+		"mongo": newMongoAuditSink,
+	}
+
+	auditOnce     sync.Once
+	auditPipeline *auditQueue
+)
+
+func init() {
+	base.RegisterConfigValidator(validateAuditConfig)
+}
+
+// validateAuditConfig 校验 `audit` 配置
+func validateAuditConfig(conf *base.BaseConfig, issues *base.ConfigIssues) {
+	audit := conf.Audit
+	if audit.MaxBodySize < 0 {
+		issues.AddError("audit.maxBodySize", "不能小于 0")
+	}
+	if !audit.Enable {
+		return
+	}
+	if len(audit.Sinks) == 0 {
+		issues.AddWarn("audit.sinks", "已开启审计日志, 但未配置任何输出")
+	}
+	for i, name := range audit.Sinks {
+		auditSinkMu.RLock()
+		_, ok := auditSinks[name]
+		auditSinkMu.RUnlock()
+		if !ok {
+			issues.AddError(fmt.Sprintf("audit.sinks[%d]", i), "未知的输出 %q", name)
+		}
+		switch name {
+		case "gorm":
+			dbName := gzutil.Ternary(audit.Gorm.DbName != "", audit.Gorm.DbName, "default")
+			found := false
+			for _, db := range conf.Db {
+				found = found || (db.Name == dbName && db.UseGorm)
+			}
+			if !found {
+				issues.AddError("audit.gorm.dbName", "databases 中不存在开启了 GORM 的数据库 %q", dbName)
+			}
+		case "mongo":
+			if audit.Mongo.Database == "" {
+				issues.AddError("audit.mongo.database", "使用 mongo 输出时必须配置")
+			}
+		}
+	}
+	if audit.QueueSize < 0 || audit.BatchSize < 0 || audit.FlushInterval < 0 {
+		issues.AddError("audit", "queueSize、batchSize、flushInterval 不能小于 0")
+	}
+}
+
+// RegisterAuditSink 注册审计日志的输出, 注册后可以在 audit.sinks 中使用
+func RegisterAuditSink(name string, sink AuditSink) {
+	auditSinkMu.Lock()
+	defer auditSinkMu.Unlock()
+
+	auditSinks[name] = func() (AuditSink, error) {
+		return sink, nil
+	}
+}
+
+// AuditStats 审计队列的统计数据
+type AuditStats struct {
+	Enqueued int64 `json:"enqueued"` // 进入队列的记录数
+	Dropped  int64 `json:"dropped"`  // 队列满时丢弃的记录数
+	Written  int64 `json:"written"`  // 成功写入的记录数(按输出累计)
+	Failed   int64 `json:"failed"`   // 写入失败的记录数(按输出累计)
+	Queued   int   `json:"queued"`   // 队列中等待写入的记录数
+}
+
+// GetAuditStats 返回审计队列的统计数据, 未开启审计时返回零值, 开启 metrics 模块时同样以 gzaudit_* 指标输出
+func GetAuditStats() AuditStats {
+	if auditPipeline == nil {
+		return AuditStats{}
+	}
+
+	return AuditStats{
+		Enqueued: auditPipeline.enqueued.Load(),
+		Dropped:  auditPipeline.dropped.Load(),
+		Written:  auditPipeline.written.Load(),
+		Failed:   auditPipeline.failed.Load(),
+		Queued:   len(auditPipeline.queue),
+	}
+}
+
+// getAuditQueue 按照 audit 配置创建审计队列, 只会创建一次, 未开启时返回 nil
+func getAuditQueue() *auditQueue {
+	auditOnce.Do(func() {
+		conf := base.GetConfig().Audit
+		if !conf.Enable {
+			return
+		}
+
+		sinks := make(map[string]AuditSink)
+		for _, name := range conf.Sinks {
+			auditSinkMu.RLock()
+			create, ok := auditSinks[name]
+			auditSinkMu.RUnlock()
+			if !ok {
+				gzconsole.Echo.Warnf("⚠️  警告: 未知的审计日志输出 %s, 已忽略\n", name)
+				continue
+			}
+			sink, err := create()
+			if err != nil {
+				gzconsole.Echo.Warnf("⚠️  警告: 审计日志输出 %s 初始化失败, 已忽略: %s\n", name, err)
+				continue
+			}
+			sinks[name] = sink
+		}
+		if len(sinks) == 0 {
+			gzconsole.Echo.Warnf("⚠️  警告: 已开启审计日志, 但没有可用的输出\n")
+			return
+		}
+
+		auditPipeline = newAuditQueue(conf.QueueSize, conf.BatchSize, time.Duration(conf.FlushInterval)*time.Second, sinks)
+		gzconsole.RegisterStop("audit", auditPipeline.Close)
+	})
+
+	return auditPipeline
+}
+
+// shouldAudit 按照请求方法和路径过滤
+func shouldAudit(method, urlPath string) bool {
+	conf := base.GetConfig().Audit
+	if len(conf.Methods) > 0 && !gzutil.InArray(strings.ToUpper(method), toUpper(conf.Methods)) {
+		return false
+	}
+	for _, pattern := range conf.ExcludePaths {
+		if matchPath(pattern, urlPath) {
+			return false
+		}
+	}
+	if len(conf.IncludePaths) == 0 {
+		return true
+	}
+	for _, pattern := range conf.IncludePaths {
+		if matchPath(pattern, urlPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPath 支持 path.Match 的通配, 以 * 结尾时按前缀匹配, 如 /api/admin/*
+func matchPath(pattern, urlPath string) bool {
+	if strings.HasSuffix(pattern, "*") && strings.HasPrefix(urlPath, strings.TrimSuffix(pattern, "*")) {
+		return true
+	}
+	matched, _ := path.Match(pattern, urlPath)
+
+	return matched
+}
+
+func toUpper(items []string) []string {
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = strings.ToUpper(item)
+	}
+
+	return result
+}
+
+// auditQueue 有界队列, 后台协程按批写入所有输出
+type auditQueue struct {
+	queue         chan *RequestLogData
+	batchSize     int
+	flushInterval time.Duration
+	sinks         map[string]AuditSink
+	stop          chan struct{}
+	done          chan struct{}
+	// mu 保证 Close 之后不会再有记录放入队列, run 在 stop 关闭后读空队列即可写入全部记录
+	mu     sync.RWMutex
+	closed bool
+
+	enqueued atomic.Int64
+	dropped  atomic.Int64
+	written  atomic.Int64
+	failed   atomic.Int64
+}
+
+func newAuditQueue(queueSize, batchSize int, flushInterval time.Duration, sinks map[string]AuditSink) *auditQueue {
+	q := &auditQueue{
+		queue:         make(chan *RequestLogData, gzutil.Ternary(queueSize > 0, queueSize, 10000)),
+		batchSize:     gzutil.Ternary(batchSize > 0, batchSize, 100),
+		flushInterval: gzutil.Ternary(flushInterval > 0, flushInterval, time.Second),
+		sinks:         sinks,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	gzutil.SafeGo(func() {
+		q.run()
+	})
+
+	return q
+}
+
+// Enqueue 放入队列, 队列已满或已关闭时丢弃, 不会阻塞请求
+func (q *auditQueue) Enqueue(record *RequestLogData) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		q.dropped.Add(1)
+		return
+	}
+
+	select {
+	case q.queue <- record:
+		q.enqueued.Add(1)
+	default:
+		if q.dropped.Add(1)%1000 == 1 {
+			base.Log.Warn("审计日志队列已满, 正在丢弃记录", zap.Int64("dropped", q.dropped.Load()))
+		}
+	}
+}
+
+// Close 停止接收新记录, 并把队列中剩余的记录全部写入
+func (q *auditQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.stop)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("审计日志未能全部写入, 剩余 %d 条: %w", len(q.queue), ctx.Err())
+	}
+}
+
+func (q *auditQueue) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*RequestLogData, 0, q.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			q.write(batch)
+			batch = make([]*RequestLogData, 0, q.batchSize)
+		}
+	}
+
+	for {
+		select {
+		case record := <-q.queue:
+			batch = append(batch, record)
+			if len(batch) >= q.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-q.stop:
+			for {
+				select {
+				case record := <-q.queue:
+					batch = append(batch, record)
+					if len(batch) >= q.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *auditQueue) write(batch []*RequestLogData) {
+	for name, sink := range q.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := fmt.Errorf("写入时发生 panic")
+		gzutil.RunSafe(func() {
+			err = sink.Write(ctx, batch)
+		})
+		cancel()
+
+		if err != nil {
+			q.failed.Add(int64(len(batch)))
+			base.Log.Error("审计日志写入失败", zap.String("sink", name), zap.Int("count", len(batch)), zap.Error(err))
+			continue
+		}
+		q.written.Add(int64(len(batch)))
+	}
+}
+
+func newLogAuditSink() (AuditSink, error) {
+	return AuditSinkFunc(func(ctx context.Context, records []*RequestLogData) error {
+		for _, record := range records {
+			base.Log.Info("[RequestLog]请求响应日志", zap.Any("logData", record))
+		}
+		return nil
+	}), nil
+}
+
+func newGormAuditSink() (AuditSink, error) {
+	conf := base.GetConfig().Audit.Gorm
+	dbName := gzutil.Ternary(conf.DbName != "", conf.DbName, "default")
+	table := gzutil.Ternary(conf.Table != "", conf.Table, "request_logs")
+	db := base.Gorm(dbName)
+	if db == nil {
+		return nil, fmt.Errorf("数据库 %s 未加载或未开启 GORM", dbName)
+	}
+	if conf.AutoMigrate {
+		if err := db.Table(table).AutoMigrate(&RequestLogData{}); err != nil {
+			return nil, fmt.Errorf("创建审计日志表 %s 失败: %s", table, err)
+		}
+	}
+
+	return AuditSinkFunc(func(ctx context.Context, records []*RequestLogData) error {
+		return db.WithContext(ctx).Table(table).Create(records).Error
+	}), nil
+}
+
+func newMongoAuditSink() (AuditSink, error) {
+	conf := base.GetConfig().Audit.Mongo
+	if base.Mdb == nil {
+		return nil, fmt.Errorf("MongoDB 模块未加载")
+	}
+	if conf.Database == "" {
+		return nil, fmt.Errorf("未配置 audit.mongo.database")
+	}
+	collection := base.Mdb.Database(conf.Database).Collection(gzutil.Ternary(conf.Collection != "", conf.Collection, "request_logs"))
+
+	return AuditSinkFunc(func(ctx context.Context, records []*RequestLogData) error {
+		documents := make([]interface{}, len(records))
+		for i, record := range records {
+			documents[i] = record
+		}
+		_, err := collection.InsertMany(ctx, documents)
+		return err
+	}), nil
+}
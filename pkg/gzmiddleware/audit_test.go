@@ -0,0 +1,43 @@
+package gzmiddleware
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAuditQueueCloseWritesEnqueued(t *testing.T) {
+	for round := 0; round < 50; round++ {
+		var written atomic.Int64
+		sink := AuditSinkFunc(func(ctx context.Context, records []*RequestLogData) error {
+			written.Add(int64(len(records)))
+			return nil
+		})
+		q := newAuditQueue(100000, 10, time.Hour, map[string]AuditSink{"count": sink})
+
+		// Close 与 Enqueue 并发时, 计入 enqueued 的记录都必须被写入
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					q.Enqueue(&RequestLogData{})
+				}
+			}()
+		}
+		if err := q.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+
+		if q.enqueued.Load() != written.Load() {
+			t.Fatalf("enqueued = %d, written = %d", q.enqueued.Load(), written.Load())
+		}
+		if q.enqueued.Load()+q.dropped.Load() != 1600 {
+			t.Fatalf("enqueued(%d) + dropped(%d) != 1600", q.enqueued.Load(), q.dropped.Load())
+		}
+	}
+}
@@ -1,14 +1,66 @@
 package gzmiddleware
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 	uuid "github.com/satori/go.uuid"
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/pkg/gztrace"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.uber.org/zap"
 )
 
+func init() {
+	// 日志中自动附加匹配到的路由, 只有传入 *gin.Context 时才能获取
+	base.RegisterLogExtractor("route", func(ctx context.Context) []zap.Field {
+		if ginCtx, ok := ctx.(*gin.Context); ok && ginCtx.FullPath() != "" {
+			return []zap.Field{zap.String("route", ginCtx.FullPath())}
+		}
+
+		return nil
+	})
+}
+
+// Begin 为每个请求生成 trace_id, 加载了 tracemodule 时会读取上游的 traceparent 并创建 span,
+// 此时 trace_id 与链路追踪的 trace id 一致, 由 base 内置的 trace 日志提取器附加到日志中, 否则使用随机的 uuid
 func Begin() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		ctx.Set("trace_id", uuid.NewV4().String())
+		route := ctx.FullPath()
+		reqCtx := gztrace.Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		reqCtx, span := gztrace.Start(reqCtx, spanName(ctx.Request.Method, route), gztrace.ServerAttributes(ctx.Request, route)...)
+		defer span.End()
+
+		// 有链路信息时 trace_id 由 base 内置的 trace 日志提取器附加, 见 base.RegisterLogExtractor
+		traceId := gztrace.TraceID(reqCtx)
+		if traceId == "" {
+			traceId = uuid.NewV4().String()
+			reqCtx = base.ContextWithLogFields(reqCtx, zap.String("trace_id", traceId))
+		}
+		ctx.Set("trace_id", traceId)
 		ctx.Set("source", "HttpRequest")
+		ctx.Request = ctx.Request.WithContext(base.ContextWithLogFields(reqCtx, zap.String("source", "HttpRequest")))
+
 		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if len(ctx.Errors) > 0 {
+			span.RecordError(ctx.Errors.Last())
+		}
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
 	}
 }
+
+func spanName(method, route string) string {
+	if route == "" {
+		return method
+	}
+
+	return method + " " + route
+}
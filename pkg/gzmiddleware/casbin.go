@@ -5,7 +5,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cast"
-	"github.com/spf13/viper"
 	"github.com/w01fb0ss/gin-starter/base"
 	"github.com/w01fb0ss/gin-starter/gzconsole"
 	"github.com/w01fb0ss/gin-starter/pkg/gzauth"
@@ -27,7 +26,7 @@ func Casbin() gin.HandlerFunc {
 			return
 		}
 
-		path := gzutil.ConvertToRestfulURL(strings.TrimPrefix(ctx.Request.URL.Path, viper.GetString("App.RouterPrefix")))
+		path := gzutil.ConvertToRestfulURL(strings.TrimPrefix(ctx.Request.URL.Path, base.GetConfig().App.RouterPrefix))
 		success, _ := base.Casbin.Enforce(cast.ToString(roleId), path, ctx.Request.Method)
 		if !success {
 			base.Fail(ctx, gzerror.NoAuth)
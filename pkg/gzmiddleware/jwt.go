@@ -1,14 +1,36 @@
 package gzmiddleware
 
 import (
+	"context"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/w01fb0ss/gin-starter/base"
 	"github.com/w01fb0ss/gin-starter/pkg/gzauth"
 	"github.com/w01fb0ss/gin-starter/pkg/gzerror"
+	"go.uber.org/zap"
 )
 
+func init() {
+	// 日志中自动附加当前用户和租户
+	base.RegisterLogExtractor("user_id", claimLogExtractor("user_id", "id"))
+	base.RegisterLogExtractor("tenant_id", claimLogExtractor("tenant_id", "tenant_id", "tenantId"))
+}
+
+// claimLogExtractor 从 Token claims 中按顺序查找 keys, 找到后以 field 作为日志字段名
+func claimLogExtractor(field string, keys ...string) base.LogExtractor {
+	return func(ctx context.Context) []zap.Field {
+		claims := gzauth.GetClaims(ctx)
+		for _, key := range keys {
+			if value, ok := claims[key]; ok {
+				return []zap.Field{zap.Any(field, value)}
+			}
+		}
+
+		return nil
+	}
+}
+
 func Jwt() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		tokenString := ctx.GetHeader("Authorization")
@@ -26,6 +48,7 @@ func Jwt() gin.HandlerFunc {
 		}
 
 		ctx.Set("claims", claims)
+		ctx.Request = ctx.Request.WithContext(gzauth.ContextWithClaims(ctx.Request.Context(), claims))
 		ctx.Next()
 	}
 }
@@ -5,43 +5,79 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
+	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/gzconsole"
 	"github.com/w01fb0ss/gin-starter/pkg/gzauth"
+	"github.com/w01fb0ss/gin-starter/pkg/gzgeo"
 	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
 )
 
 type RequestLogData struct {
-	Username   string `json:"username"`   // 用户名
-	UserId     int64  `json:"userId"`     // 用户ID
-	Method     string `json:"method"`     // 请求方法
-	Path       string `json:"path"`       // 请求路径
-	StatusCode int64  `json:"statusCode"` // 状态码
-	Elapsed    string `json:"elapsed"`    // 耗时
-	Msg        string `json:"msg"`        // 返回的msg
-	Request    string `json:"request"`    // 请求参数
-	Response   string `json:"response"`   // 返回参数
-	Platform   string `json:"platform"`   // 平台
-	Ip         string `json:"ip"`         // IP
-	Address    string `json:"address"`    // 地址
+	TraceId    string    `json:"traceId" bson:"traceId"`       // 链路ID
+	Username   string    `json:"username" bson:"username"`     // 用户名
+	UserId     int64     `json:"userId" bson:"userId"`         // 用户ID
+	Method     string    `json:"method" bson:"method"`         // 请求方法
+	Path       string    `json:"path" bson:"path"`             // 请求路径
+	StatusCode int64     `json:"statusCode" bson:"statusCode"` // HTTP 状态码
+	Code       int64     `json:"code" bson:"code"`             // 业务状态码, 即返回的code
+	Elapsed    string    `json:"elapsed" bson:"elapsed"`       // 耗时
+	Msg        string    `json:"msg" bson:"msg"`               // 返回的msg
+	Request    string    `json:"request" bson:"request"`       // 请求参数
+	Response   string    `json:"response" bson:"response"`     // 返回参数
+	Platform   string    `json:"platform" bson:"platform"`     // 平台
+	Ip         string    `json:"ip" bson:"ip"`                 // IP
+	Address    string    `json:"address" bson:"address"`       // 地址
+	CreatedAt  time.Time `json:"createdAt" bson:"createdAt"`   // 请求时间
 }
 
+// defaultMaxBodySize 请求和响应超过该大小时不记录内容
+const defaultMaxBodySize = 64 << 10
+
+var geoOnce sync.Once
+
+// initGeoProvider 配置了 ip.geoDb 并且没有自定义归属地查询时, 加载 xdb 离线库
+func initGeoProvider() {
+	geoOnce.Do(func() {
+		file := base.GetConfig().Ip.GeoDb
+		if file == "" || gzgeo.HasProvider() {
+			return
+		}
+		provider, err := gzgeo.NewXdbProvider(file)
+		if err != nil {
+			gzconsole.Echo.Warnf("⚠️  警告: IP 归属地库加载失败, 将不记录归属地: %s\n", err)
+			return
+		}
+		gzgeo.SetProvider(provider)
+	})
+}
+
+// RequestLog 采集请求和响应, 结果保存在 ctx 的 RequestLogData 中, 开启 audit 后会异步写入审计日志
+// multipart 上传、超过 audit.maxBodySize 以及二进制的内容不会被记录
 func RequestLog() gin.HandlerFunc {
+	queue := getAuditQueue()
+	initGeoProvider()
+
 	return func(ctx *gin.Context) {
+		maxBodySize := base.GetConfig().Audit.MaxBodySize
+		if maxBodySize <= 0 {
+			maxBodySize = defaultMaxBodySize
+		}
+
 		path, id := gzutil.GetRequestPath(ctx.Request.URL.Path, "/api")
 		body := make(map[string]interface{})
 		if id != 0 {
 			body["id"] = id
 		}
-
-		if ctx.Request.Body != nil {
-			bodyPost, _ := io.ReadAll(ctx.Request.Body)
-			ctx.Request.Body = io.NopCloser(bytes.NewBuffer(bodyPost))
-			body["post"] = string(bodyPost)
+		if ctx.Request.Body != nil && ctx.Request.Body != http.NoBody {
+			body["post"] = captureRequestBody(ctx.Request, maxBodySize)
 		}
 
 		query := ctx.Request.URL.RawQuery
@@ -50,59 +86,141 @@ func RequestLog() gin.HandlerFunc {
 			for _, v := range strings.Split(query, "&") {
 				kv := strings.Split(v, "=")
 				if len(kv) == 2 {
-					body[kv[0]] = kv[1]
+					body[kv[0]] = base.Redactor().String(kv[0], kv[1])
 				}
 			}
 		}
 
 		request, _ := json.Marshal(body)
 		userAgent := ctx.GetHeader("User-Agent")
+		ip := gzutil.GetClientRealIP(ctx)
 		logData := RequestLogData{
+			TraceId:  ctx.GetString("trace_id"),
 			Method:   ctx.Request.Method,
 			Path:     path,
 			Request:  string(request),
 			UserId:   gzauth.GetTokenValue[int64](ctx, "id"),
 			Username: gzauth.GetTokenValue[string](ctx, "username"),
 			Platform: gzutil.GetPlatform(userAgent) + " " + gzutil.GetBrowser(userAgent),
+			Ip:       ip,
+			Address:  gzgeo.Lookup(ip),
 		}
 
 		writer := &responseBodyWriter{
 			ResponseWriter: ctx.Writer,
 			body:           &bytes.Buffer{},
+			maxSize:        maxBodySize,
 		}
 		ctx.Writer = writer
 		startTime := time.Now()
+		logData.CreatedAt = startTime
 
 		ctx.Next()
 
 		elapsedMs := time.Since(startTime).Seconds() * 1000
 		logData.Elapsed = fmt.Sprintf("%.2f", elapsedMs)
-		resp := &base.Response{}
-		_ = json.Unmarshal([]byte(writer.body.String()), resp)
-		logData.StatusCode = resp.Code
-		logData.Msg = resp.Msg
-		respData, _ := json.Marshal(resp.Data)
-		logData.Response = string(respData)
-		// base.Log.Info("[RequestLog]请求响应日志", zap.Any("logData", logData))
-
-		// 非 Get 请求把数据放入Context中
-		// if ctx.Request.Method != "GET" {
-		//
-		// }
+		logData.StatusCode = int64(writer.Status())
+		switch {
+		case writer.skipped != "":
+			logData.Response = writer.skipped
+		case writer.body.Len() > 0:
+			resp := &base.Response{}
+			if err := json.Unmarshal(writer.body.Bytes(), resp); err == nil {
+				logData.Code = resp.Code
+				logData.Msg = resp.Msg
+				respData, _ := json.Marshal(resp.Data)
+				logData.Response = string(base.Redactor().JSON(respData))
+			} else {
+				logData.Response = string(base.Redactor().JSON(writer.body.Bytes()))
+			}
+		}
 		ctx.Set("RequestLogData", &logData)
+
+		// 复制一份放入审计队列, 避免后续中间件修改 ctx 中的数据
+		if queue != nil && shouldAudit(ctx.Request.Method, ctx.Request.URL.Path) {
+			record := logData
+			queue.Enqueue(&record)
+		}
 	}
 }
 
+// captureRequestBody 读取请求体用于记录, 并放回 Request.Body 供后续处理
+// 最多读取 maxSize+1 个字节, 超出的部分不会被缓存, 避免大文件上传占用内存
+func captureRequestBody(req *http.Request, maxSize int) string {
+	contentType := req.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "multipart/") {
+		return "[multipart 内容已忽略]"
+	}
+	if req.ContentLength > int64(maxSize) {
+		return fmt.Sprintf("[请求体 %d 字节, 超过 %d 字节已忽略]", req.ContentLength, maxSize)
+	}
+
+	captured, _ := io.ReadAll(io.LimitReader(req.Body, int64(maxSize)+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(captured), req.Body), req.Body}
+
+	if len(captured) > maxSize {
+		return fmt.Sprintf("[请求体超过 %d 字节已忽略]", maxSize)
+	}
+	if !isTextContent(contentType, captured) {
+		return "[二进制内容已忽略]"
+	}
+
+	return string(base.Redactor().JSON(captured))
+}
+
+// isTextContent 根据 Content-Type 判断是否为文本, 未设置时根据内容检测
+func isTextContent(contentType string, data []byte) bool {
+	if contentType == "" {
+		if len(data) == 0 {
+			return true
+		}
+		contentType = http.DetectContentType(data)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/x-www-form-urlencoded" ||
+		mediaType == "application/javascript"
+}
+
 type responseBodyWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body    *bytes.Buffer
+	maxSize int
+	skipped string // 不记录响应内容的原因
 }
 
 func (r *responseBodyWriter) Write(b []byte) (int, error) {
-	r.body.Write(b)
+	r.capture(b)
 	return r.ResponseWriter.Write(b)
 }
 
+func (r *responseBodyWriter) WriteString(s string) (int, error) {
+	r.capture([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *responseBodyWriter) capture(b []byte) {
+	if r.skipped != "" {
+		return
+	}
+	if r.body.Len() == 0 && !isTextContent(r.Header().Get("Content-Type"), b) {
+		r.skipped = "[二进制内容已忽略]"
+		return
+	}
+	if r.body.Len()+len(b) > r.maxSize {
+		r.skipped = fmt.Sprintf("[响应超过 %d 字节已忽略]", r.maxSize)
+		r.body.Reset()
+		return
+	}
+	r.body.Write(b)
+}
+
 func (r *responseBodyWriter) WriteHeader(statusCode int) {
 	if !r.Written() {
 		r.ResponseWriter.WriteHeader(statusCode)
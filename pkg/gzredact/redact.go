@@ -0,0 +1,260 @@
+package gzredact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// 脱敏方式
+const (
+	StyleFull    = "full"    // 全部隐藏: ******
+	StylePartial = "partial" // 保留首尾: 138****0000
+	StyleHash    = "hash"    // 替换为哈希, 相同的值哈希相同, 便于排查: sha256:9f86d081884c7d65
+)
+
+// Rule 脱敏规则, Keys 和 Pattern 至少配置一个
+type Rule struct {
+	// Keys 需要脱敏的字段名, 不区分大小写并忽略 `_`、`-`, 如 password 同时匹配 Password、pass_word
+	// 包含 `.` 时按照 JSON 路径匹配, 如 user.idCard, 数组不需要写下标
+	Keys []string `mapstructure:"keys"`
+	// Pattern 对所有字符串值生效的正则, 匹配到的部分会被脱敏
+	Pattern string `mapstructure:"pattern"`
+	// Style 脱敏方式, 默认 full
+	Style string `mapstructure:"style"`
+}
+
+// DefaultRules 内置的脱敏规则
+var DefaultRules = []Rule{
+	{Keys: []string{"password", "passwd", "pwd", "token", "accessToken", "refreshToken", "secret", "secretKey", "authorization", "cookie"}, Style: StyleFull},
+	{Keys: []string{"phone", "mobile", "idCard", "idNumber", "bankCard"}, Style: StylePartial},
+	{Pattern: `\b1[3-9]\d{9}\b`, Style: StylePartial},                                    // 手机号
+	{Pattern: `\b\d{6}(?:18|19|20)\d{2}[01]\d[0-3]\d\d{3}[\dXx]\b`, Style: StylePartial}, // 身份证号
+}
+
+type regexRule struct {
+	re    *regexp.Regexp
+	style string
+}
+
+// Redactor 脱敏器, 创建后只读, 可以在多个协程中使用
+type Redactor struct {
+	keys    map[string]string
+	paths   map[string]string
+	regexes []regexRule
+}
+
+// New 根据规则创建脱敏器
+func New(rules []Rule) (*Redactor, error) {
+	r := &Redactor{
+		keys:  make(map[string]string),
+		paths: make(map[string]string),
+	}
+	for i, rule := range rules {
+		style := rule.Style
+		if style == "" {
+			style = StyleFull
+		}
+		if style != StyleFull && style != StylePartial && style != StyleHash {
+			return nil, fmt.Errorf("第 %d 条规则: 不支持的脱敏方式 %q, 可选值: full、partial、hash", i+1, style)
+		}
+		if len(rule.Keys) == 0 && rule.Pattern == "" {
+			return nil, fmt.Errorf("第 %d 条规则: keys 和 pattern 至少配置一个", i+1)
+		}
+
+		for _, key := range rule.Keys {
+			if strings.Contains(key, ".") {
+				r.paths[normalizePath(key)] = style
+			} else {
+				r.keys[normalizeKey(key)] = style
+			}
+		}
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 条规则: 正则 %q 错误: %s", i+1, rule.Pattern, err)
+			}
+			r.regexes = append(r.regexes, regexRule{re: re, style: style})
+		}
+	}
+
+	return r, nil
+}
+
+func normalizeKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+}
+
+func normalizePath(path string) string {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		parts[i] = normalizeKey(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// keyStyle 返回字段对应的脱敏方式, path 为完整的 JSON 路径
+func (r *Redactor) keyStyle(path string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	path = normalizePath(path)
+	if style, ok := r.paths[path]; ok {
+		return style, true
+	}
+	style, ok := r.keys[path[strings.LastIndex(path, ".")+1:]]
+
+	return style, ok
+}
+
+// MatchKey 字段名或 JSON 路径是否需要脱敏
+func (r *Redactor) MatchKey(path string) bool {
+	_, ok := r.keyStyle(path)
+
+	return ok
+}
+
+// Text 对字符串应用所有正则规则
+func (r *Redactor) Text(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, rule := range r.regexes {
+		s = rule.re.ReplaceAllStringFunc(s, func(match string) string {
+			return Mask(match, rule.style)
+		})
+	}
+
+	return s
+}
+
+// String 脱敏一个字段, 字段名匹配时按照字段的规则处理, 否则应用正则规则
+func (r *Redactor) String(path, value string) string {
+	if style, ok := r.keyStyle(path); ok {
+		return Mask(value, style)
+	}
+
+	return r.Text(value)
+}
+
+// Value 递归脱敏 map、slice 中的值, 返回新的值, 不会修改原值
+func (r *Redactor) Value(path string, value interface{}) interface{} {
+	if r == nil {
+		return value
+	}
+	if style, ok := r.keyStyle(path); ok && value != nil {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			return Mask(fmt.Sprint(value), style)
+		}
+	}
+
+	switch val := value.(type) {
+	case string:
+		return r.Text(val)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			result[k] = r.Value(joinPath(path, k), item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, item := range val {
+			result[i] = r.Value(path, item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// JSON 脱敏 JSON 数据, 不是 JSON 时按照表单(a=1&b=2)或普通文本处理
+func (r *Redactor) JSON(data []byte) []byte {
+	if r == nil || len(data) == 0 {
+		return data
+	}
+
+	if out, err := r.jsonAt("", data); err == nil {
+		return out
+	}
+	if form, err := url.ParseQuery(string(data)); err == nil && len(form) > 0 && strings.Contains(string(data), "=") {
+		return []byte(strings.ReplaceAll(r.Form(form).Encode(), "%2A", "*"))
+	}
+
+	return []byte(r.Text(string(data)))
+}
+
+// Any 脱敏任意可以序列化为 JSON 的值, 如结构体, path 为该值所在的路径, 返回脱敏后的 JSON
+func (r *Redactor) Any(path string, value interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(value)
+	if err != nil || r == nil {
+		return data, err
+	}
+
+	return r.jsonAt(path, data)
+}
+
+// jsonAt 解析 JSON 后脱敏, 使用 json.Number 避免大整数丢失精度
+func (r *Redactor) jsonAt(path string, data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("JSON 后存在多余的内容")
+	}
+
+	return json.Marshal(r.Value(path, value))
+}
+
+// Form 脱敏表单或 URL 参数
+func (r *Redactor) Form(form url.Values) url.Values {
+	result := make(url.Values, len(form))
+	for key, values := range form {
+		for _, value := range values {
+			result.Add(key, r.String(key, value))
+		}
+	}
+
+	return result
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// Mask 按照指定方式脱敏
+func Mask(value, style string) string {
+	switch style {
+	case StylePartial:
+		runes := []rune(value)
+		n := len(runes)
+		switch {
+		case n >= 8:
+			return string(runes[:3]) + strings.Repeat("*", n-7) + string(runes[n-4:])
+		case n >= 3:
+			return string(runes[:1]) + strings.Repeat("*", n-2) + string(runes[n-1:])
+		default:
+			return strings.Repeat("*", n)
+		}
+	case StyleHash:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	default:
+		return "******"
+	}
+}
@@ -0,0 +1,162 @@
+package gzredact
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newDefault(t *testing.T) *Redactor {
+	t.Helper()
+
+	r, err := New(DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestMask(t *testing.T) {
+	cases := []struct {
+		value string
+		style string
+		want  string
+	}{
+		{"13800001234", StylePartial, "138****1234"},
+		{"abcd", StylePartial, "a**d"},
+		{"张三丰", StylePartial, "张*丰"},
+		{"ab", StylePartial, "**"},
+		{"secret", StyleFull, "******"},
+		{"secret", "", "******"},
+		{"test", StyleHash, "sha256:9f86d081884c7d65"},
+	}
+	for _, c := range cases {
+		if got := Mask(c.value, c.style); got != c.want {
+			t.Errorf("Mask(%q, %q) = %q, 期望 %q", c.value, c.style, got, c.want)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	cases := map[string][]Rule{
+		"不支持的脱敏方式": {{Keys: []string{"a"}, Style: "blur"}},
+		"至少配置一个":   {{Style: StyleFull}},
+		"正则":       {{Pattern: "("}},
+	}
+	for want, rules := range cases {
+		if _, err := New(rules); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("错误为 %v, 期望包含 %q", err, want)
+		}
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	r, err := New([]Rule{{Keys: []string{"password", "user.idCard"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]bool{
+		"password":       true,
+		"Password":       true,
+		"pass_word":      true,
+		"user.PASS-WORD": true,
+		"user.id_card":   true,
+		"idCard":         false,
+		"order.idCard":   false,
+		"username":       false,
+	}
+	for path, want := range cases {
+		if got := r.MatchKey(path); got != want {
+			t.Errorf("MatchKey(%q) = %v, 期望 %v", path, got, want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	r := newDefault(t)
+
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "按字段名脱敏, 包括嵌套和数组",
+			in:   `{"user":{"name":"tom","password":"123456"},"items":[{"token":"abc"}]}`,
+			want: `{"items":[{"token":"******"}],"user":{"name":"tom","password":"******"}}`,
+		},
+		{
+			name: "非字符串的值同样脱敏",
+			in:   `{"pwd":123456,"secret":{"a":"b"}}`,
+			want: `{"pwd":"******","secret":{"a":"b"}}`,
+		},
+		{
+			name: "正则匹配所有字符串值",
+			in:   `{"remark":"联系电话 13800001234"}`,
+			want: `{"remark":"联系电话 138****1234"}`,
+		},
+		{
+			name: "大整数不丢失精度",
+			in:   `{"id":9007199254740993}`,
+			want: `{"id":9007199254740993}`,
+		},
+		{
+			name: "表单",
+			in:   `password=123456&name=tom`,
+			want: `name=tom&password=******`,
+		},
+		{
+			name: "普通文本",
+			in:   `call 13800001234`,
+			want: `call 138****1234`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := string(r.JSON([]byte(c.in))); got != c.want {
+				t.Fatalf("JSON = %s, 期望 %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestValueDoesNotModifyInput(t *testing.T) {
+	r := newDefault(t)
+
+	in := map[string]interface{}{"password": "123456"}
+	out := r.Value("", in).(map[string]interface{})
+	if in["password"] != "123456" || out["password"] != "******" {
+		t.Fatalf("in = %v, out = %v", in, out)
+	}
+}
+
+func TestForm(t *testing.T) {
+	r := newDefault(t)
+
+	got := r.Form(url.Values{"Authorization": {"Bearer x"}, "mobile": {"13800001234"}, "q": {"go"}})
+	if got.Get("Authorization") != "******" || got.Get("mobile") != "138****1234" || got.Get("q") != "go" {
+		t.Fatalf("Form = %v", got)
+	}
+}
+
+func TestAny(t *testing.T) {
+	r := newDefault(t)
+
+	type login struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	got, err := r.Any("", login{Name: "tom", Password: "123456"})
+	if err != nil || string(got) != `{"name":"tom","password":"******"}` {
+		t.Fatalf("Any = %s, %v", got, err)
+	}
+}
+
+func TestNilRedactor(t *testing.T) {
+	var r *Redactor
+	if r.MatchKey("password") || r.Text("13800001234") != "13800001234" || string(r.JSON([]byte(`{"password":"1"}`))) != `{"password":"1"}` {
+		t.Fatal("nil 脱敏器不应修改数据")
+	}
+}
@@ -0,0 +1,138 @@
+package gztls
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CertStore 保存多组证书, 按 SNI 选择, 证书文件变化后自动重新加载
+// 检查在握手时进行, 每个间隔最多检查一次, 不需要额外的协程
+type CertStore struct {
+	pairs    []CertPair
+	interval time.Duration
+	onReload func(err error)
+
+	certs   atomic.Pointer[[]*tls.Certificate]
+	checked atomic.Int64 // 上次检查的时间, UnixNano
+
+	mu    sync.Mutex
+	stats []fileStat
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewCertStore 加载证书, interval 为 0 时不检查文件变化
+func NewCertStore(pairs []CertPair, interval time.Duration, onReload func(err error)) (*CertStore, error) {
+	if len(pairs) == 0 {
+		return nil, errors.New("至少需要一个证书")
+	}
+
+	store := &CertStore{pairs: pairs, interval: interval, onReload: onReload}
+	store.checked.Store(time.Now().UnixNano())
+	store.stats, _ = store.stat()
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// GetCertificate 用于 tls.Config.GetCertificate, 返回第一个与客户端匹配的证书
+func (s *CertStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.maybeReload()
+
+	certs := *s.certs.Load()
+	for _, cert := range certs {
+		if hello.SupportsCertificate(cert) == nil {
+			return cert, nil
+		}
+	}
+
+	return certs[0], nil
+}
+
+// Reload 立即重新加载所有证书, 失败时继续使用旧证书
+func (s *CertStore) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats, _ = s.stat()
+	return s.load()
+}
+
+func (s *CertStore) maybeReload() {
+	if s.interval <= 0 {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := s.checked.Load()
+	if now-last < int64(s.interval) || !s.checked.CompareAndSwap(last, now) {
+		return
+	}
+
+	s.mu.Lock()
+	stats, err := s.stat()
+	// 证书可能正在被替换, 文件不完整时等下次检查
+	if err != nil || !s.changed(stats) {
+		s.mu.Unlock()
+		return
+	}
+	s.stats = stats
+	err = s.load()
+	s.mu.Unlock()
+
+	if s.onReload != nil {
+		s.onReload(err)
+	}
+}
+
+func (s *CertStore) load() error {
+	certs := make([]*tls.Certificate, 0, len(s.pairs))
+	for _, pair := range s.pairs {
+		cert, err := tls.LoadX509KeyPair(pair.CertFile, pair.KeyFile)
+		if err != nil {
+			return fmt.Errorf("加载证书 %s 失败: %s", pair.CertFile, err)
+		}
+		certs = append(certs, &cert)
+	}
+	s.certs.Store(&certs)
+
+	return nil
+}
+
+// stat 读取所有证书文件的修改时间和大小, 软链接会读取目标文件, 兼容 certbot 等工具的更新方式
+func (s *CertStore) stat() ([]fileStat, error) {
+	stats := make([]fileStat, 0, len(s.pairs)*2)
+	for _, pair := range s.pairs {
+		for _, file := range []string{pair.CertFile, pair.KeyFile} {
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, err
+			}
+			stats = append(stats, fileStat{modTime: info.ModTime(), size: info.Size()})
+		}
+	}
+
+	return stats, nil
+}
+
+func (s *CertStore) changed(stats []fileStat) bool {
+	if len(stats) != len(s.stats) {
+		return true
+	}
+	for i, stat := range stats {
+		if !stat.modTime.Equal(s.stats[i].modTime) || stat.size != s.stats[i].size {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,133 @@
+package gztls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// CertPair 一组证书和私钥文件
+type CertPair struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Options 创建 tls.Config 的参数
+type Options struct {
+	Certs          []CertPair      // 证书列表, 按 SNI 选择, 没有匹配时使用第一个
+	MinVersion     string          // 最低版本: 1.0、1.1、1.2、1.3, 默认 1.2; 用于 HTTP/3 时需要 1.3
+	CipherSuites   []string        // 加密套件名称, 见 tls.CipherSuites(), 只对 TLS 1.2 及以下生效, 默认使用 Go 的推荐列表
+	ClientCa       string          // 校验客户端证书的 CA 文件, 设置后开启双向认证
+	ClientAuth     string          // 客户端认证方式: none、request、require、verifyIfGiven、requireAndVerify, 设置 ClientCa 时默认 requireAndVerify
+	ReloadInterval time.Duration   // 检查证书文件变化的间隔, 0 时不检查
+	OnReload       func(err error) // 证书重新加载后调用, 失败时 err 不为空, 此时继续使用旧证书
+}
+
+// NewConfig 创建 tls.Config, 证书通过 GetCertificate 提供, 证书文件变化后自动重新加载
+//
+//	config, err := gztls.NewConfig(gztls.Options{
+//		Certs:          []gztls.CertPair{{CertFile: "a.com.pem", KeyFile: "a.com.key"}, {CertFile: "b.com.pem", KeyFile: "b.com.key"}},
+//		ReloadInterval: time.Minute,
+//	})
+func NewConfig(opts Options) (*tls.Config, error) {
+	minVersion, err := ParseVersion(opts.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	cipherSuites, err := ParseCipherSuites(opts.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	clientAuth, err := ParseClientAuth(opts.ClientAuth)
+	if err != nil {
+		return nil, err
+	}
+	store, err := NewCertStore(opts.Certs, opts.ReloadInterval, opts.OnReload)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion:     minVersion,
+		CipherSuites:   cipherSuites,
+		ClientAuth:     clientAuth,
+		GetCertificate: store.GetCertificate,
+	}
+	if opts.ClientCa != "" {
+		pem, err := os.ReadFile(opts.ClientCa)
+		if err != nil {
+			return nil, fmt.Errorf("读取客户端 CA 失败: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("客户端 CA %s 中没有有效的证书", opts.ClientCa)
+		}
+		config.ClientCAs = pool
+		if opts.ClientAuth == "" {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return config, nil
+}
+
+// ParseVersion 解析 TLS 版本, 为空时返回 TLS 1.2
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "":
+		return tls.VersionTLS12, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, fmt.Errorf("不支持的 TLS 版本 %q, 可选值: 1.0、1.1、1.2、1.3", version)
+}
+
+// ParseCipherSuites 按名称解析加密套件, 为空时返回 nil 使用 Go 的默认列表, 名称不区分大小写
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	suites := make(map[string]uint16)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		suites[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := suites[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("未知的加密套件 %q", name)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// ParseClientAuth 解析客户端认证方式, 为空时不要求客户端证书
+func ParseClientAuth(clientAuth string) (tls.ClientAuthType, error) {
+	switch clientAuth {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verifyIfGiven":
+		return tls.VerifyClientCertIfGiven, nil
+	case "requireAndVerify":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+
+	return 0, fmt.Errorf("不支持的客户端认证方式 %q, 可选值: none、request、require、verifyIfGiven、requireAndVerify", clientAuth)
+}
@@ -0,0 +1,86 @@
+package gztrace
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "gztrace:span"
+
+// GormPlugin 为 GORM 的增删改查创建 span, 需要通过 db.WithContext(ctx) 传入请求的 context 才能关联到请求的链路
+// system 为数据库类型, 如 mysql、postgresql
+//
+//	db.Use(gztrace.GormPlugin("mysql", "default"))
+func GormPlugin(system, dbName string) gorm.Plugin {
+	return &gormPlugin{attrs: []attribute.KeyValue{
+		semconv.DBSystemNameKey.String(system),
+		semconv.DBNamespace(dbName),
+	}}
+}
+
+type gormPlugin struct {
+	attrs []attribute.KeyValue
+}
+
+func (p *gormPlugin) Name() string {
+	return "gztrace"
+}
+
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	errs := []error{
+		callback.Create().Before("gorm:create").Register("gztrace:before_create", p.before("create")),
+		callback.Create().After("gorm:create").Register("gztrace:after_create", p.after),
+		callback.Query().Before("gorm:query").Register("gztrace:before_query", p.before("query")),
+		callback.Query().After("gorm:query").Register("gztrace:after_query", p.after),
+		callback.Update().Before("gorm:update").Register("gztrace:before_update", p.before("update")),
+		callback.Update().After("gorm:update").Register("gztrace:after_update", p.after),
+		callback.Delete().Before("gorm:delete").Register("gztrace:before_delete", p.before("delete")),
+		callback.Delete().After("gorm:delete").Register("gztrace:after_delete", p.after),
+		callback.Row().Before("gorm:row").Register("gztrace:before_row", p.before("row")),
+		callback.Row().After("gorm:row").Register("gztrace:after_row", p.after),
+		callback.Raw().Before("gorm:raw").Register("gztrace:before_raw", p.before("raw")),
+		callback.Raw().After("gorm:raw").Register("gztrace:after_raw", p.after),
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *gormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(p.attrs...),
+			trace.WithAttributes(semconv.DBOperationName(operation)),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p *gormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+
+	// 只记录带占位符的 SQL, 不记录参数, 避免泄露敏感数据
+	span.SetAttributes(semconv.DBQueryText(db.Statement.SQL.String()))
+	if db.Statement.RowsAffected >= 0 {
+		span.SetAttributes(semconv.DBResponseReturnedRows(int(db.Statement.RowsAffected)))
+	}
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
@@ -0,0 +1,80 @@
+package gztrace
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transport 包装 http.RoundTripper, 为每个请求创建 client span, 并通过 traceparent 请求头传递给下游
+// base 为空时使用 http.DefaultTransport
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			// 只记录到路径, 查询参数中可能包含 token 等敏感信息
+			semconv.URLFull(req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+			semconv.ServerAddress(req.URL.Hostname()),
+		),
+	)
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		span.SetAttributes(semconv.ServerPort(port))
+	}
+
+	// RoundTripper 不能修改传入的请求, 需要复制后再写入请求头
+	req = req.Clone(ctx)
+	Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		End(span, err)
+		return resp, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+	}
+	span.End()
+
+	return resp, nil
+}
+
+// ServerAttributes 服务端 span 的通用属性
+func ServerAttributes(req *http.Request, route string) []trace.SpanStartOption {
+	attrs := trace.WithAttributes(
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLPath(req.URL.Path),
+		semconv.UserAgentOriginal(req.UserAgent()),
+	)
+	opts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindServer), attrs}
+	if route != "" {
+		opts = append(opts, trace.WithAttributes(semconv.HTTPRoute(route)))
+	}
+	if host, _, err := net.SplitHostPort(req.Host); err == nil {
+		opts = append(opts, trace.WithAttributes(semconv.ServerAddress(host)))
+	} else if req.Host != "" {
+		opts = append(opts, trace.WithAttributes(semconv.ServerAddress(req.Host)))
+	}
+
+	return opts
+}
@@ -0,0 +1,64 @@
+package gztrace
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook 为 Redis 命令创建 span, 只记录命令名称, 不记录参数
+//
+//	client.AddHook(gztrace.RedisHook())
+func RedisHook() redis.Hook {
+	return redisHook{}
+}
+
+type redisHook struct{}
+
+func (redisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = Start(ctx, "redis."+cmd.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameRedis, semconv.DBOperationName(cmd.Name())),
+	)
+
+	return ctx, nil
+}
+
+func (redisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	End(trace.SpanFromContext(ctx), redisError(cmd.Err()))
+	return nil
+}
+
+func (redisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	ctx, _ = Start(ctx, "redis.pipeline",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNameRedis, attribute.Int("db.operation.batch.size", len(cmds))),
+	)
+
+	return ctx, nil
+}
+
+func (redisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if err = redisError(cmd.Err()); err != nil {
+			break
+		}
+	}
+	End(trace.SpanFromContext(ctx), err)
+
+	return nil
+}
+
+// redisError key 不存在不算错误
+func redisError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+
+	return err
+}
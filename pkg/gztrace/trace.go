@@ -0,0 +1,63 @@
+package gztrace
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 本项目创建的 span 都使用这个 Tracer 名称
+const instrumentationName = "github.com/w01fb0ss/gin-starter"
+
+// Tracer 返回全局 TracerProvider 中的 Tracer, 未开启链路追踪时为空实现, 不会产生开销
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start 开始一个 span, 使用完后需要调用 End
+//
+//	ctx, span := gztrace.Start(ctx, "order.create")
+//	defer func() { gztrace.End(span, err) }()
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End 结束 span, err 不为空时记录错误并把状态设为 Error
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID 返回 ctx 中的 trace id, 没有时返回空字符串
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+
+	return ""
+}
+
+// SpanID 返回 ctx 中的 span id, 没有时返回空字符串
+func SpanID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasSpanID() {
+		return sc.SpanID().String()
+	}
+
+	return ""
+}
+
+// Extract 从请求头中提取上游传递的链路信息, 如 W3C traceparent
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// Inject 将 ctx 中的链路信息写入请求头, 传递给下游服务
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
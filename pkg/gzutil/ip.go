@@ -54,31 +54,9 @@ func GetLocalIP() string {
 	return "unknown"
 }
 
-// GetClientRealIP 获取客户端真实 IP（适用于 Gin 框架）
+// GetClientRealIP 获取客户端真实 IP（适用于 Gin 框架）, 规则见 ClientIP
 func GetClientRealIP(c *gin.Context) string {
-	// 先检查 X-Forwarded-For 头
-	xff := c.GetHeader("X-Forwarded-For")
-	if xff != "" {
-		// XFF 是逗号分隔的 IP 列表，第一个通常是客户端 IP
-		ips := strings.Split(xff, ",")
-		for _, ip := range ips {
-			ip = strings.TrimSpace(ip)
-			if net.ParseIP(ip) != nil {
-				return ip
-			}
-		}
-	}
-
-	// 再检查 X-Real-IP
-	xri := c.GetHeader("X-Real-IP")
-	if xri != "" {
-		if net.ParseIP(xri) != nil {
-			return xri
-		}
-	}
-
-	// 最后使用 RemoteAddr
-	return getIPFromRemoteAddr(c.Request.RemoteAddr)
+	return ClientIP(c.Request)
 }
 
 // getIPFromRemoteAddr 从 RemoteAddr 中提取 IP 地址（格式可能是 IP:PORT）
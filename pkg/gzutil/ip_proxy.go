@@ -0,0 +1,185 @@
+package gzutil
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultTrustedProxies 默认只信任本机的反向代理
+var DefaultTrustedProxies = []string{"127.0.0.0/8", "::1/128"}
+
+// DefaultClientIPHeaders 默认按顺序读取的请求头
+// 使用 CDN 时可以加入 CDN 设置的请求头, 如 CF-Connecting-IP(Cloudflare)、True-Client-IP(Akamai)、Ali-Cdn-Real-Ip(阿里云)
+var DefaultClientIPHeaders = []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"}
+
+type proxyConfig struct {
+	trusted []*net.IPNet
+	headers []string
+}
+
+var clientIPConfig atomic.Pointer[proxyConfig]
+
+func init() {
+	_ = SetTrustedProxies(DefaultTrustedProxies, DefaultClientIPHeaders)
+}
+
+// SetTrustedProxies 设置可信代理(IP 或 CIDR)和读取客户端 IP 的请求头, headers 为空时使用 DefaultClientIPHeaders
+// 只有直接连接的对端是可信代理时才会读取请求头, 否则直接使用 RemoteAddr, 防止客户端伪造 IP
+func SetTrustedProxies(proxies []string, headers []string) error {
+	trusted, err := ParseIPNets(proxies)
+	if err != nil {
+		return err
+	}
+	if len(headers) == 0 {
+		headers = DefaultClientIPHeaders
+	}
+	canonical := make([]string, len(headers))
+	for i, header := range headers {
+		canonical[i] = http.CanonicalHeaderKey(strings.TrimSpace(header))
+	}
+	clientIPConfig.Store(&proxyConfig{trusted: trusted, headers: canonical})
+
+	return nil
+}
+
+// ParseIPNets 解析 IP 或 CIDR 列表, 单个 IP 视为 /32 或 /128
+func ParseIPNets(items []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if strings.Contains(item, "/") {
+			_, ipNet, err := net.ParseCIDR(item)
+			if err != nil {
+				return nil, fmt.Errorf("%q 不是有效的 CIDR", item)
+			}
+			nets = append(nets, ipNet)
+			continue
+		}
+		ip := net.ParseIP(item)
+		if ip == nil {
+			return nil, fmt.Errorf("%q 不是有效的 IP", item)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+
+	return nets, nil
+}
+
+// IsTrustedProxy 是否为可信代理
+func IsTrustedProxy(ip net.IP) bool {
+	conf := clientIPConfig.Load()
+	if ip == nil || conf == nil {
+		return false
+	}
+	for _, ipNet := range conf.trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP 获取客户端真实 IP
+//  1. 对端不是可信代理时直接返回 RemoteAddr 中的 IP
+//  2. 按顺序读取配置的请求头, Forwarded 和 X-Forwarded-For 从右往左跳过可信代理, 取第一个不可信的地址
+//  3. 其它请求头(如 X-Real-IP、CDN 请求头)只包含一个地址, 直接使用
+func ClientIP(r *http.Request) string {
+	remote := getIPFromRemoteAddr(r.RemoteAddr)
+	conf := clientIPConfig.Load()
+	if conf == nil || !IsTrustedProxy(net.ParseIP(remote)) {
+		return remote
+	}
+
+	for _, header := range conf.headers {
+		values := r.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+
+		var ip string
+		switch header {
+		case "Forwarded":
+			ip = walkProxyChain(parseForwarded(values))
+		case "X-Forwarded-For":
+			ip = walkProxyChain(splitHeaderList(values))
+		default:
+			ip = normalizeIP(values[0])
+		}
+		if ip != "" {
+			return ip
+		}
+	}
+
+	return remote
+}
+
+// walkProxyChain 从右往左跳过可信代理, 全部可信时返回最左侧的地址, 遇到无法解析的地址时放弃该请求头
+func walkProxyChain(chain []string) string {
+	var ip string
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip = normalizeIP(chain[i])
+		if ip == "" {
+			return ""
+		}
+		if !IsTrustedProxy(net.ParseIP(ip)) {
+			return ip
+		}
+	}
+
+	return ip
+}
+
+// parseForwarded 解析 RFC 7239 的 Forwarded 请求头, 返回 for 参数列表
+// Forwarded: for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"
+func parseForwarded(values []string) []string {
+	var chain []string
+	for _, element := range splitHeaderList(values) {
+		forValue := ""
+		for _, pair := range strings.Split(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(key, "for") {
+				forValue = strings.Trim(value, `"`)
+			}
+		}
+		chain = append(chain, forValue)
+	}
+
+	return chain
+}
+
+func splitHeaderList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	}
+
+	return items
+}
+
+// normalizeIP 去掉端口和 IPv6 的方括号, 无效时返回空字符串
+func normalizeIP(value string) string {
+	value = strings.TrimSpace(value)
+	if ip := net.ParseIP(value); ip != nil {
+		return ip.String()
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		if ip := net.ParseIP(host); ip != nil {
+			return ip.String()
+		}
+	}
+	if ip := net.ParseIP(strings.Trim(value, "[]")); ip != nil {
+		return ip.String()
+	}
+
+	return ""
+}
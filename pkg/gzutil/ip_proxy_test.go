@@ -0,0 +1,123 @@
+package gzutil
+
+import (
+	"net/http"
+	"testing"
+)
+
+func useTrustedProxies(t *testing.T, proxies, headers []string) {
+	t.Helper()
+
+	if err := SetTrustedProxies(proxies, headers); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = SetTrustedProxies(DefaultTrustedProxies, DefaultClientIPHeaders)
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	useTrustedProxies(t, []string{"10.0.0.0/8", "192.168.1.1"}, []string{"Forwarded", "X-Forwarded-For", "CF-Connecting-IP", "X-Real-IP"})
+
+	cases := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "不可信的对端伪造 X-Forwarded-For",
+			remote:  "203.0.113.9:5000",
+			headers: map[string]string{"X-Forwarded-For": "1.1.1.1"},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "不可信的对端伪造 CDN 请求头",
+			remote:  "203.0.113.9:5000",
+			headers: map[string]string{"CF-Connecting-IP": "1.1.1.1"},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "从右往左遇到第一个不可信的地址为止",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.7, 10.0.0.5, 192.168.1.1"},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "全部可信时使用最左侧的地址",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"X-Forwarded-For": "10.1.1.1, 10.0.0.5"},
+			want:    "10.1.1.1",
+		},
+		{
+			name:    "无法解析的地址放弃该请求头",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"X-Forwarded-For": "1.1.1.1, garbage", "X-Real-IP": "198.51.100.8"},
+			want:    "198.51.100.8",
+		},
+		{
+			name:    "Forwarded 中带端口的 IPv6",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https, for=10.0.0.5`},
+			want:    "2001:db8::1",
+		},
+		{
+			name:    "Forwarded 优先于 X-Forwarded-For",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"Forwarded": "for=198.51.100.1", "X-Forwarded-For": "198.51.100.2"},
+			want:    "198.51.100.1",
+		},
+		{
+			name:    "Forwarded 中混淆的节点, 放弃该请求头",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"Forwarded": "for=198.51.100.1, for=_hidden", "X-Forwarded-For": "198.51.100.2"},
+			want:    "198.51.100.2",
+		},
+		{
+			name:    "Forwarded 中的 unknown 节点, 没有其他请求头时使用对端地址",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"Forwarded": "for=unknown"},
+			want:    "10.0.0.2",
+		},
+		{
+			name:    "Forwarded 中没有 for 参数",
+			remote:  "10.0.0.2:5000",
+			headers: map[string]string{"Forwarded": "proto=https;by=10.0.0.5", "X-Real-IP": "198.51.100.3"},
+			want:    "198.51.100.3",
+		},
+		{
+			name:    "可信代理设置的 CDN 请求头",
+			remote:  "192.168.1.1:443",
+			headers: map[string]string{"CF-Connecting-IP": "2001:db8::2"},
+			want:    "2001:db8::2",
+		},
+		{
+			name:   "没有请求头时使用对端地址",
+			remote: "[::1]:5000",
+			want:   "::1",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: c.remote, Header: http.Header{}}
+			for key, value := range c.headers {
+				r.Header.Set(key, value)
+			}
+			if got := ClientIP(r); got != c.want {
+				t.Fatalf("ClientIP = %q, 期望 %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseIPNets(t *testing.T) {
+	nets, err := ParseIPNets([]string{"10.0.0.0/8", "192.168.1.1", " ::1 "})
+	if err != nil || len(nets) != 3 || nets[1].String() != "192.168.1.1/32" || nets[2].String() != "::1/128" {
+		t.Fatalf("ParseIPNets = %v, %v", nets, err)
+	}
+	for _, item := range []string{"10.0.0.0/33", "not-an-ip"} {
+		if _, err = ParseIPNets([]string{item}); err == nil {
+			t.Errorf("%q 应返回错误", item)
+		}
+	}
+}
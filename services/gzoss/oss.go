@@ -8,8 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/w01fb0ss/gin-starter/base"
 	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
-	"github.com/spf13/viper"
 )
 
 type ossType string
@@ -48,7 +48,7 @@ func NewLocal() oss {
 }
 
 func NewByConf() oss {
-	return start(ossType(viper.GetString("Oss.Type")))
+	return start(ossType(base.GetConfig().Oss.Type))
 }
 
 func start(ossType ossType) oss {
@@ -68,7 +68,7 @@ func getUploadDirAndFilename(fileHeader *multipart.FileHeader, uploadDir ...stri
 	if len(uploadDir) > 0 {
 		dir = uploadDir[0]
 	} else {
-		dir = viper.GetString("Oss.SavePath")
+		dir = base.GetConfig().Oss.SavePath
 	}
 	if dir == "" {
 		dir = "./static/storage/attach/"
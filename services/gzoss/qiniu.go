@@ -9,7 +9,8 @@ import (
 	"github.com/qiniu/go-sdk/v7/storagev2/credentials"
 	"github.com/qiniu/go-sdk/v7/storagev2/http_client"
 	"github.com/qiniu/go-sdk/v7/storagev2/uploader"
-	"github.com/spf13/viper"
+	"github.com/w01fb0ss/gin-starter/base"
+	"github.com/w01fb0ss/gin-starter/pkg/gzutil"
 )
 
 type qiNiu struct{}
@@ -18,10 +19,11 @@ func (*qiNiu) Upload(file multipart.File, fileHeader *multipart.FileHeader, uplo
 	// 获取上传目录和文件名
 	savePathUri, filename := getUploadDirAndFilename(fileHeader, uploadDir...)
 
-	accessKey := viper.GetString("Oss.AccessKey")
-	secretKey := viper.GetString("Oss.SecretKey")
-	bucket := viper.GetString("Oss.Bucket")
-	ossUrl := viper.GetString("Oss.Url")
+	conf := base.GetConfig().Oss
+	accessKey := conf.AccessKey
+	secretKey := conf.SecretKey
+	bucket := gzutil.Ternary(conf.BucketName != "", conf.BucketName, conf.Bucket)
+	ossUrl := conf.Url
 	if accessKey == "" || secretKey == "" || ossUrl == "" || bucket == "" {
 		return nil, errors.New("config has empty value")
 	}